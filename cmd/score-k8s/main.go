@@ -8,6 +8,7 @@ The Apache Software Foundation (http://www.apache.org/).
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,8 +16,9 @@ import (
 )
 
 func main() {
-	if err := command.Execute(); err != nil {
+	code, err := command.Execute()
+	if err != nil && !errors.Is(err, command.ErrDifferencesFound) {
 		_, _ = fmt.Fprintln(os.Stderr, "Error: "+err.Error())
-		os.Exit(1)
 	}
+	os.Exit(code)
 }
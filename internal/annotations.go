@@ -22,6 +22,48 @@ const (
 	AnnotationPrefix              = "k8s.score.dev/"
 	WorkloadKindAnnotation        = AnnotationPrefix + "kind"
 	WorkloadServiceNameAnnotation = AnnotationPrefix + "service-name"
+
+	// Annotations that only apply to the Job and CronJob workload kinds.
+
+	WorkloadScheduleAnnotation          = AnnotationPrefix + "schedule"
+	WorkloadConcurrencyPolicyAnnotation = AnnotationPrefix + "concurrency-policy"
+	WorkloadBackoffLimitAnnotation      = AnnotationPrefix + "backoff-limit"
+	WorkloadCompletionsAnnotation       = AnnotationPrefix + "completions"
+	WorkloadParallelismAnnotation       = AnnotationPrefix + "parallelism"
+	WorkloadRestartPolicyAnnotation     = AnnotationPrefix + "restart-policy"
+
+	// Annotations that control where the pods of a workload are scheduled and how DaemonSets are rolled out.
+
+	WorkloadNodeSelectorAnnotation   = AnnotationPrefix + "node-selector"
+	WorkloadTolerationsAnnotation    = AnnotationPrefix + "tolerations"
+	WorkloadMaxUnavailableAnnotation = AnnotationPrefix + "max-unavailable"
+
+	// Annotations that control the number of replicas of Deployment and StatefulSet workloads.
+
+	WorkloadReplicasAnnotation                = AnnotationPrefix + "replicas"
+	WorkloadMinReplicasAnnotation             = AnnotationPrefix + "min-replicas"
+	WorkloadMaxReplicasAnnotation             = AnnotationPrefix + "max-replicas"
+	WorkloadTargetCPUUtilizationAnnotation    = AnnotationPrefix + "target-cpu-utilization"
+	WorkloadTargetMemoryUtilizationAnnotation = AnnotationPrefix + "target-memory-utilization"
+
+	// Annotations that generate a PodDisruptionBudget for Deployment and StatefulSet workloads.
+
+	WorkloadPdbMinAvailableAnnotation   = AnnotationPrefix + "pdb-min-available"
+	WorkloadPdbMaxUnavailableAnnotation = AnnotationPrefix + "pdb-max-unavailable"
+
+	// Annotations that override the security context settings of the security profile for the pods of a workload.
+
+	WorkloadRunAsNonRootAnnotation             = AnnotationPrefix + "run-as-non-root"
+	WorkloadAllowPrivilegeEscalationAnnotation = AnnotationPrefix + "allow-privilege-escalation"
+	WorkloadReadOnlyRootFilesystemAnnotation   = AnnotationPrefix + "read-only-root-filesystem"
+	WorkloadAddCapabilitiesAnnotation          = AnnotationPrefix + "add-capabilities"
+	WorkloadSeccompProfileAnnotation           = AnnotationPrefix + "seccomp-profile"
+
+	// Annotations that control the ServiceAccount of the pods of a workload. The token is automounted by default only
+	// when a resource of the workload returns rbac rules.
+
+	WorkloadServiceAccountNameAnnotation           = AnnotationPrefix + "service-account-name"
+	WorkloadAutomountServiceAccountTokenAnnotation = AnnotationPrefix + "automount-service-account-token"
 )
 
 func ListAnnotations(metadata map[string]interface{}) []string {
@@ -0,0 +1,249 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	diffCmdOutputFlag = "output"
+
+	maskedSecretValue        = "<masked>"
+	maskedChangedSecretValue = "<masked:changed>"
+)
+
+// ErrDifferencesFound is returned by the diff command when the generated manifests differ from the existing output
+// file. The caller should exit with the exit code returned by Execute without reporting it as an error.
+var ErrDifferencesFound = errors.New("differences found")
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Args:  cobra.ArbitraryArgs,
+	Short: "Show the changes that generate would make to the existing manifests file",
+	Long: `The diff command runs the same conversion as the generate command, but without persisting the state or writing
+the manifests. Instead, the generated manifests are compared with the existing manifests file by apiVersion, kind,
+namespace, and name and a unified diff is printed for each manifest that was added, removed, or changed. The values in
+the data of Secrets are masked.
+
+The diff command has no side effects. Resources that are not yet in the state and are provisioned by a cmd or http
+provisioner are not provisioned, since these provisioners may create or change things outside the project. Instead,
+these resources are listed as pending at the start of the output, they have no manifests, and their outputs are
+replaced by "<pending>" in the manifests that use them. Run generate to provision them.
+
+The command exits with code 1 when there are differences, 0 when there are none, and 2 when the manifests cannot be
+generated or compared.
+`,
+	Example: `
+  # Show the changes for the current project
+  score-k8s diff
+
+  # Show the changes after adding or updating Score files
+  score-k8s diff score.yaml *.score.yaml
+
+  # Compare against a different manifests file
+  score-k8s diff score.yaml --output=./deploy/manifests.yaml`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		outputFile, _ := cmd.Flags().GetString(diffCmdOutputFlag)
+		existing, err := readExistingManifests(outputFile)
+		if err != nil {
+			return err
+		}
+
+		generated, err := generateManifests(cmd, args, false)
+		if err != nil {
+			return err
+		}
+
+		for _, resUid := range generated.pending {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "# pending resource '%s': its provisioner is only run by generate\n", resUid)
+		}
+		differences, err := writeManifestDiffs(cmd.OutOrStdout(), existing, generated.manifests)
+		if err != nil {
+			return err
+		}
+		if differences > 0 {
+			slog.Info(fmt.Sprintf("Found differences in %d manifests compared to '%s'", differences, outputFile))
+			return ErrDifferencesFound
+		}
+		slog.Info(fmt.Sprintf("No differences compared to '%s'", outputFile))
+		return nil
+	},
+}
+
+// readExistingManifests decodes the manifests in the multi-document yaml file. A missing file has no manifests.
+func readExistingManifests(path string) ([]map[string]interface{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			slog.Warn(fmt.Sprintf("Manifests file '%s' does not exist, all manifests will be shown as added", path))
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read existing manifests file: %w", err)
+	}
+	out := make([]map[string]interface{}, 0)
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	for {
+		var manifest map[string]interface{}
+		if err := dec.Decode(&manifest); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode existing manifests file '%s': %w", path, err)
+		}
+		if manifest != nil {
+			out = append(out, manifest)
+		}
+	}
+	return out, nil
+}
+
+// writeManifestDiffs writes a unified diff for each manifest that differs between the existing and generated manifests
+// and returns the number of manifests that differ. Manifests are matched on their apiVersion, kind, namespace, and name.
+func writeManifestDiffs(w io.Writer, existing []map[string]interface{}, generated []map[string]interface{}) (int, error) {
+	existingBySignature := make(map[string]map[string]interface{}, len(existing))
+	for _, m := range existing {
+		existingBySignature[buildManifestSignature(m)] = m
+	}
+	generatedSignatures := make(map[string]bool, len(generated))
+
+	var differences int
+	writeDiff := func(signature string, before, after map[string]interface{}) error {
+		before, after = maskSecretData(before, after)
+		beforeText, err := encodeManifestForDiff(before)
+		if err != nil {
+			return err
+		}
+		afterText, err := encodeManifestForDiff(after)
+		if err != nil {
+			return err
+		}
+		if beforeText == afterText {
+			return nil
+		}
+		differences++
+		fromFile, toFile := "existing/"+signature, "generated/"+signature
+		if before == nil {
+			fromFile = "/dev/null"
+		} else if after == nil {
+			toFile = "/dev/null"
+		}
+		return difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+			A:        splitDiffLines(beforeText),
+			B:        splitDiffLines(afterText),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+	}
+
+	for _, m := range generated {
+		signature := buildManifestSignature(m)
+		generatedSignatures[signature] = true
+		if err := writeDiff(signature, existingBySignature[signature], m); err != nil {
+			return 0, fmt.Errorf("failed to diff manifest %s: %w", signature, err)
+		}
+	}
+	for _, m := range existing {
+		signature := buildManifestSignature(m)
+		if generatedSignatures[signature] {
+			continue
+		}
+		if err := writeDiff(signature, m, nil); err != nil {
+			return 0, fmt.Errorf("failed to diff manifest %s: %w", signature, err)
+		}
+	}
+	return differences, nil
+}
+
+// encodeManifestForDiff encodes the manifest with the same yaml encoding as the generate command so that unchanged
+// manifests produce identical text.
+func encodeManifestForDiff(manifest map[string]interface{}) (string, error) {
+	if manifest == nil {
+		return "", nil
+	}
+	out := new(bytes.Buffer)
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(manifest); err != nil {
+		return "", fmt.Errorf("failed to encode manifest as yaml: %w", err)
+	}
+	return out.String(), nil
+}
+
+// splitDiffLines splits the text into lines that keep their line endings.
+func splitDiffLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// maskSecretData replaces the values in the data and stringData of Secret manifests so that they are not printed. A
+// value that changed is masked differently on the generated side so that the change is still visible.
+func maskSecretData(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	isSecret := func(m map[string]interface{}) bool {
+		return m != nil && m["kind"] == "Secret" && m["apiVersion"] == "v1"
+	}
+	if !isSecret(before) && !isSecret(after) {
+		return before, after
+	}
+	maskedBefore, maskedAfter := maps.Clone(before), maps.Clone(after)
+	for _, field := range []string{"data", "stringData"} {
+		beforeData, _ := before[field].(map[string]interface{})
+		afterData, _ := after[field].(map[string]interface{})
+		if beforeData != nil && isSecret(before) {
+			masked := make(map[string]interface{}, len(beforeData))
+			for k := range beforeData {
+				masked[k] = maskedSecretValue
+			}
+			maskedBefore[field] = masked
+		}
+		if afterData != nil && isSecret(after) {
+			masked := make(map[string]interface{}, len(afterData))
+			for k, v := range afterData {
+				if previous, ok := beforeData[k]; ok && !reflect.DeepEqual(previous, v) {
+					masked[k] = maskedChangedSecretValue
+				} else {
+					masked[k] = maskedSecretValue
+				}
+			}
+			maskedAfter[field] = masked
+		}
+	}
+	return maskedBefore, maskedAfter
+}
+
+func init() {
+	diffCmd.Flags().StringP(diffCmdOutputFlag, "o", "manifests.yaml", "The existing manifests file to compare the generated manifests against")
+	addGenerateInputFlags(diffCmd)
+	rootCmd.AddCommand(diffCmd)
+}
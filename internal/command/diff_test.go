@@ -0,0 +1,211 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func TestDiffWithoutInit(t *testing.T) {
+	_ = changeToTempDir(t)
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff"})
+	assert.EqualError(t, err, "state directory does not exist, please run \"score-k8s init\" first")
+	assert.Equal(t, "", stdout)
+}
+
+func TestDiff(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://dummy
+  type: dummy
+  manifests: |
+    - apiVersion: v1
+      kind: Secret
+      metadata:
+        name: my-secret
+      data:
+        fruit: {{ .Params.fruit | b64enc }}
+        colour: {{ b64enc "red" }}
+`), 0644))
+	writeScore := func(image string, fruit string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: `+image+`
+resources:
+  thing:
+    type: dummy
+    params:
+      fruit: `+fruit+`
+`), 0644))
+	}
+	writeScore("nginx:1", "banana")
+
+	t.Run("missing output file", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff", "score.yaml"})
+		assert.ErrorIs(t, err, ErrDifferencesFound)
+		assert.Contains(t, stdout, "--- /dev/null\n+++ generated/apps/v1/Deployment//example\n")
+		assert.Contains(t, stdout, "+++ generated/v1/Secret//my-secret\n")
+
+		// the state was not persisted
+		sd, ok, err := project.LoadStateDirectory(".")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Empty(t, sd.State.Workloads)
+	})
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml"})
+	require.NoError(t, err)
+
+	t.Run("no differences", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff"})
+		assert.NoError(t, err)
+		assert.Equal(t, "", stdout)
+	})
+
+	t.Run("changed manifests", func(t *testing.T) {
+		writeScore("nginx:2", "apple")
+		raw, err := os.ReadFile("manifests.yaml")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile("manifests.yaml", append(raw, []byte(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: stale
+`)...), 0644))
+
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff", "score.yaml"})
+		assert.ErrorIs(t, err, ErrDifferencesFound)
+		assert.Contains(t, stdout, `--- existing/apps/v1/Deployment//example
++++ generated/apps/v1/Deployment//example
+`)
+		assert.Contains(t, stdout, "-        - image: nginx:1\n+        - image: nginx:2\n")
+		assert.Contains(t, stdout, `--- existing/v1/Secret//my-secret
++++ generated/v1/Secret//my-secret
+@@ -1,7 +1,7 @@
+ apiVersion: v1
+ data:
+   colour: <masked>
+-  fruit: <masked>
++  fruit: <masked:changed>
+ kind: Secret
+ metadata:
+   name: my-secret
+`)
+		assert.Contains(t, stdout, `--- existing/v1/ConfigMap//stale
++++ /dev/null
+@@ -1,4 +0,0 @@
+-apiVersion: v1
+-kind: ConfigMap
+-metadata:
+-  name: stale
+`)
+		assert.NotContains(t, stdout, "YmFuYW5h")
+		assert.NotContains(t, stdout, "YXBwbGU=")
+	})
+}
+
+func TestDiffExitCode(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+`), 0644))
+
+	t.Run("differences", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff", "score.yaml"})
+		assert.ErrorIs(t, err, ErrDifferencesFound)
+		assert.Equal(t, 1, exitCode(diffCmd, err))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff", "missing.yaml"})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrDifferencesFound)
+		assert.Equal(t, 2, exitCode(diffCmd, err))
+	})
+
+	t.Run("other commands", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "missing.yaml"})
+		assert.Error(t, err)
+		assert.Equal(t, 1, exitCode(generateCmd, err))
+		assert.Equal(t, 0, exitCode(diffCmd, nil))
+	})
+}
+
+func TestDiffPendingExternalProvisioner(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: cmd://sh
+  type: thing
+  args: ["-c", "touch provisioned && echo '{\"resource_outputs\":{\"url\":\"https://example.com\"}}'"]
+  outputs: ["url"]
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+    variables:
+      URL: ${resources.thing.url}
+resources:
+  thing:
+    type: thing
+`), 0644))
+
+	t.Run("new resources are pending", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff", "score.yaml"})
+		assert.ErrorIs(t, err, ErrDifferencesFound)
+		assert.True(t, strings.HasPrefix(stdout, "# pending resource 'thing.default#example.thing': its provisioner is only run by generate\n"))
+		assert.Contains(t, stdout, "+              value: <pending>\n")
+		assert.NoFileExists(t, filepath.Join(td, "provisioned"))
+	})
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml"})
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(td, "provisioned"))
+	require.NoError(t, os.Remove(filepath.Join(td, "provisioned")))
+
+	t.Run("resources in the state are provisioned", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"diff", "score.yaml"})
+		assert.NoError(t, err)
+		assert.Equal(t, "", stdout)
+		assert.FileExists(t, filepath.Join(td, "provisioned"))
+	})
+}
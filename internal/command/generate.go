@@ -16,11 +16,12 @@ package command
 
 import (
 	"bytes"
-	"context"
 	"crypto/rand"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"path"
 	"slices"
 	"strings"
 
@@ -40,7 +41,10 @@ import (
 	"github.com/score-spec/score-k8s/internal/patching"
 	"github.com/score-spec/score-k8s/internal/project"
 	"github.com/score-spec/score-k8s/internal/provisioners"
+	"github.com/score-spec/score-k8s/internal/provisioners/cmdprov"
+	"github.com/score-spec/score-k8s/internal/provisioners/httpprov"
 	"github.com/score-spec/score-k8s/internal/provisioners/loader"
+	"github.com/score-spec/score-k8s/internal/validation"
 )
 
 const (
@@ -51,11 +55,26 @@ const (
 	generateCmdNamespaceFlag         = "namespace"
 	generateCmdGenerateNamespaceFlag = "generate-namespace"
 	generateCmdFormatFlag            = "format"
+	generateCmdOutputDirFlag         = "output-dir"
+	generateCmdChartVersionFlag      = "chart-version"
+	generateCmdPatchManifestsFlag    = "patch-manifests"
+	generateCmdValidateFlag          = "validate"
+	generateCmdKubeVersionFlag       = "kube-version"
+	generateCmdCrdSchemasFlag        = "crd-schemas"
+	generateCmdNoDeprovisionFlag     = "no-deprovision"
+	generateCmdParallelismFlag       = "parallelism"
+	generateCmdProvisionTimeoutFlag  = "provision-timeout"
+	generateCmdNetworkPoliciesFlag   = "network-policies"
+	generateCmdSecurityProfileFlag   = "security-profile"
+
+	// defaultProvisioningParallelism is the default number of provisioning requests that run at the same time.
+	defaultProvisioningParallelism = 4
 )
 
 const (
-	outputFormatYaml  = "yaml"
-	outputFormatKyaml = "kyaml"
+	outputFormatYaml      = "yaml"
+	outputFormatKyaml     = "kyaml"
+	outputFormatHelmChart = "helm-chart"
 )
 
 var generateCmd = &cobra.Command{
@@ -63,7 +82,9 @@ var generateCmd = &cobra.Command{
 	Args:  cobra.ArbitraryArgs,
 	Short: "Convert one or more Score files into a set of Kubernetes manifests",
 	Long: `The generate command will convert Score files in the current Score state into a combined set of Kubernetes
-manifests. All resources and links between Workloads will be resolved and provisioned as required.
+manifests. All resources and links between Workloads will be resolved and provisioned as required. Resources in the
+state that are no longer referenced by any Workload are deprovisioned and removed from the state unless
+--no-deprovision is set.
 
 "score-k8s init" MUST be run first. An error will be thrown if the project directory is not present.
 `,
@@ -89,271 +110,443 @@ manifests. All resources and links between Workloads will be resolved and provis
   # Generate namespace manifest and set namespace for all resources
   score-k8s generate score.yaml --namespace=test-ns --generate-namespace
 
+  # Only allow ingress to workloads and resources from the workloads that depend on them
+  score-k8s generate score.yaml --network-policies
+
+  # Harden the workload pods to meet the restricted Pod Security Standard
+  score-k8s generate score.yaml --security-profile=restricted
+
   # Generate manifests in the KYAML format instead of YAML
-  score-k8s generate score.yaml --format=kyaml`,
+  score-k8s generate score.yaml --format=kyaml
+
+  # Validate the generated manifests offline for a target Kubernetes version
+  score-k8s generate score.yaml --validate --kube-version=1.30 --crd-schemas=./crds
+
+  # Write one file per manifest and a kustomization.yaml into a directory
+  score-k8s generate score.yaml --output-dir=./manifests
+
+  # Write a Helm chart with the workload images, replicas, and resources in the values.yaml
+  score-k8s generate score.yaml --format=helm-chart --output-dir=./chart --chart-version=1.2.3`,
 	SilenceErrors: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 
-		// Check if generate-namespace is set without namespace
-		generateNamespace, _ := cmd.Flags().GetBool(generateCmdGenerateNamespaceFlag)
-		namespace, _ := cmd.Flags().GetString(generateCmdNamespaceFlag)
-		if generateNamespace && namespace == "" {
-			return fmt.Errorf("--namespace flag is required when using --generate-namespace")
+		outputFormat, _ := cmd.Flags().GetString(generateCmdFormatFlag)
+		if outputFormat != outputFormatYaml && outputFormat != outputFormatKyaml && outputFormat != outputFormatHelmChart {
+			return fmt.Errorf("invalid --%s value %q, expected %q, %q, or %q", generateCmdFormatFlag, outputFormat, outputFormatYaml, outputFormatKyaml, outputFormatHelmChart)
 		}
 
-		outputFormat, _ := cmd.Flags().GetString(generateCmdFormatFlag)
-		if outputFormat != outputFormatYaml && outputFormat != outputFormatKyaml {
-			return fmt.Errorf("invalid --%s value %q, expected %q or %q", generateCmdFormatFlag, outputFormat, outputFormatYaml, outputFormatKyaml)
+		outputDir, _ := cmd.Flags().GetString(generateCmdOutputDirFlag)
+		if outputDir != "" && cmd.Flags().Lookup(generateCmdOutputFlag).Changed {
+			return fmt.Errorf("cannot use --%s and --%s together", generateCmdOutputFlag, generateCmdOutputDirFlag)
+		} else if outputDir == "" && outputFormat == outputFormatHelmChart {
+			return fmt.Errorf("--%s is required when using --%s=%s", generateCmdOutputDirFlag, generateCmdFormatFlag, outputFormatHelmChart)
 		}
 
-		sd, ok, err := project.LoadStateDirectory(".")
-		if err != nil {
-			return fmt.Errorf("failed to load existing state directory: %w", err)
-		} else if !ok {
-			return fmt.Errorf("state directory does not exist, please run \"score-k8s init\" first")
+		var manifestValidator *validation.Validator
+		if v, _ := cmd.Flags().GetBool(generateCmdValidateFlag); v {
+			kubeVersion, _ := cmd.Flags().GetString(generateCmdKubeVersionFlag)
+			crdSchemas, _ := cmd.Flags().GetString(generateCmdCrdSchemasFlag)
+			var err error
+			if manifestValidator, err = validation.NewValidator(kubeVersion, crdSchemas); err != nil {
+				return fmt.Errorf("failed to set up --%s: %w", generateCmdValidateFlag, err)
+			}
+		} else if cmd.Flags().Lookup(generateCmdKubeVersionFlag).Changed || cmd.Flags().Lookup(generateCmdCrdSchemasFlag).Changed {
+			return fmt.Errorf("--%s flag is required when using --%s or --%s", generateCmdValidateFlag, generateCmdKubeVersionFlag, generateCmdCrdSchemasFlag)
 		}
-		state := &sd.State
 
-		if len(args) != 1 && (cmd.Flags().Lookup(generateCmdOverridesFileFlag).Changed || cmd.Flags().Lookup(generateCmdOverridePropertyFlag).Changed || cmd.Flags().Lookup(generateCmdImageFlag).Changed) {
-			return errors.Errorf("cannot use --%s, --%s, or --%s when 0 or more than 1 score files are provided", generateCmdOverridePropertyFlag, generateCmdOverridesFileFlag, generateCmdImageFlag)
+		generated, err := generateManifests(cmd, args, true)
+		if err != nil {
+			return err
 		}
+		state, outputManifests, manifestSources := generated.state, generated.manifests, generated.sources
 
-		slices.Sort(args)
-		var validationErrors []string
-		for _, arg := range args {
-			var rawWorkload map[string]interface{}
-			if raw, err := os.ReadFile(arg); err != nil {
-				return errors.Wrapf(err, "failed to read input score file: %s", arg)
-			} else if err = yaml.Unmarshal(raw, &rawWorkload); err != nil {
-				return errors.Wrapf(err, "failed to decode input score file: %s", arg)
+		if manifestValidator != nil {
+			if err := validateManifests(manifestValidator, outputManifests, manifestSources, state); err != nil {
+				return err
 			}
+		}
 
-			// Early validation: check for missing metadata before schema validation
-			meta, ok := rawWorkload["metadata"].(map[string]interface{})
-			if !ok {
-				validationErrors = append(validationErrors, fmt.Sprintf("workload in file '%s' is missing required metadata", arg))
-				continue
-			}
-			if name, _ := meta["name"].(string); name == "" {
-				validationErrors = append(validationErrors, fmt.Sprintf("workload in file '%s' has empty metadata.name", arg))
-				continue
-			}
+		if outputFormat == outputFormatHelmChart {
+			chartVersion, _ := cmd.Flags().GetString(generateCmdChartVersionFlag)
+			return writeHelmChart(outputDir, chartVersion, outputManifests, manifestSources, state)
+		} else if outputDir != "" {
+			return writeManifestsToDirectory(outputDir, outputManifests, manifestSources, outputFormat)
+		}
 
-			// apply overrides
+		out, err := encodeManifests(outputManifests, outputFormat)
+		if err != nil {
+			return err
+		}
+		v, _ := cmd.Flags().GetString(generateCmdOutputFlag)
+		if v == "" {
+			return fmt.Errorf("no output file specified")
+		} else if v == "-" {
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), out.String())
+		} else if err := os.WriteFile(v+".tmp", out.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		} else if err := os.Rename(v+".tmp", v); err != nil {
+			return fmt.Errorf("failed to complete writing output file: %w", err)
+		} else {
+			slog.Info(fmt.Sprintf("Wrote manifests to '%s'", v))
+		}
+		return nil
+	},
+}
 
-			if v, _ := cmd.Flags().GetString(generateCmdOverridesFileFlag); v != "" {
-				if err := parseAndApplyOverrideFile(v, generateCmdOverridesFileFlag, rawWorkload); err != nil {
-					return err
-				}
-			}
+// generatedManifests is the result of running the generate pipeline.
+type generatedManifests struct {
+	state     *project.State
+	manifests []map[string]interface{}
+	// sources tracks the workload name or resource uid that produced each manifest
+	sources map[string]string
+	// pending are the resources that were not provisioned because the state is not persisted
+	pending []framework.ResourceUid
+}
 
-			// Now read, parse, and apply any override properties to the score files
-			if v, _ := cmd.Flags().GetStringArray(generateCmdOverridePropertyFlag); len(v) > 0 {
-				for _, overridePropertyEntry := range v {
-					if rawWorkload, err = parseAndApplyOverrideProperty(overridePropertyEntry, generateCmdOverridePropertyFlag, rawWorkload); err != nil {
-						return err
-					}
-				}
-			}
+// isExternalProvisioner returns whether the provisioner runs a command or calls an http endpoint, which may have side
+// effects outside the project.
+func isExternalProvisioner(provisioner provisioners.Provisioner) bool {
+	switch provisioner.(type) {
+	case *cmdprov.Provisioner, *httpprov.Provisioner:
+		return true
+	}
+	return false
+}
 
-			// Ensure transforms are applied (be a good citizen)
-			if changes, err := scoreschema.ApplyCommonUpgradeTransforms(rawWorkload); err != nil {
-				return fmt.Errorf("failed to upgrade spec: %w", err)
-			} else if len(changes) > 0 {
-				for _, change := range changes {
-					slog.Info(fmt.Sprintf("Applying backwards compatible upgrade %s", change))
-				}
-			}
+// generateManifests runs the generate pipeline: it adds the score files to the project state, provisions the resources,
+// converts the workloads, and applies any patches. The state is only persisted when persist is true.
+func generateManifests(cmd *cobra.Command, args []string, persist bool) (*generatedManifests, error) {
+	// Check if generate-namespace is set without namespace
+	generateNamespace, _ := cmd.Flags().GetBool(generateCmdGenerateNamespaceFlag)
+	namespace, _ := cmd.Flags().GetString(generateCmdNamespaceFlag)
+	if generateNamespace && namespace == "" {
+		return nil, fmt.Errorf("--namespace flag is required when using --generate-namespace")
+	}
+	parallelism, _ := cmd.Flags().GetInt(generateCmdParallelismFlag)
+	if parallelism < 1 {
+		return nil, fmt.Errorf("--%s must be at least 1", generateCmdParallelismFlag)
+	}
+	networkPolicies, _ := cmd.Flags().GetBool(generateCmdNetworkPoliciesFlag)
+	securityProfile, _ := cmd.Flags().GetString(generateCmdSecurityProfileFlag)
+	if !slices.Contains(convert.SecurityProfiles, convert.SecurityProfile(securityProfile)) {
+		return nil, fmt.Errorf("invalid --%s value %q, expected %q, %q, or %q", generateCmdSecurityProfileFlag, securityProfile, convert.SecurityProfileRestricted, convert.SecurityProfileBaseline, convert.SecurityProfileNone)
+	}
+	provisionTimeout, _ := cmd.Flags().GetDuration(generateCmdProvisionTimeoutFlag)
+	if provisionTimeout < 0 {
+		return nil, fmt.Errorf("--%s must not be negative", generateCmdProvisionTimeoutFlag)
+	}
+
+	sd, ok, err := project.LoadStateDirectory(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load existing state directory: %w", err)
+	} else if !ok {
+		return nil, fmt.Errorf("state directory does not exist, please run \"score-k8s init\" first")
+	}
+	state := &sd.State
+
+	if len(args) != 1 && (cmd.Flags().Lookup(generateCmdOverridesFileFlag).Changed || cmd.Flags().Lookup(generateCmdOverridePropertyFlag).Changed || cmd.Flags().Lookup(generateCmdImageFlag).Changed) {
+		return nil, errors.Errorf("cannot use --%s, --%s, or --%s when 0 or more than 1 score files are provided", generateCmdOverridePropertyFlag, generateCmdOverridesFileFlag, generateCmdImageFlag)
+	}
+
+	slices.Sort(args)
+	var validationErrors []string
+	for _, arg := range args {
+		var rawWorkload map[string]interface{}
+		if raw, err := os.ReadFile(arg); err != nil {
+			return nil, errors.Wrapf(err, "failed to read input score file: %s", arg)
+		} else if err = yaml.Unmarshal(raw, &rawWorkload); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode input score file: %s", arg)
+		}
+
+		// Early validation: check for missing metadata before schema validation
+		meta, ok := rawWorkload["metadata"].(map[string]interface{})
+		if !ok {
+			validationErrors = append(validationErrors, fmt.Sprintf("workload in file '%s' is missing required metadata", arg))
+			continue
+		}
+		if name, _ := meta["name"].(string); name == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("workload in file '%s' has empty metadata.name", arg))
+			continue
+		}
 
-			var workload scoretypes.Workload
-			if err = scoreschema.Validate(rawWorkload); err != nil {
-				return errors.Wrapf(err, "invalid score file: %s", arg)
-			} else if err = scoreloader.MapSpec(&workload, rawWorkload); err != nil {
-				return errors.Wrapf(err, "failed to decode input score file: %s", arg)
+		// apply overrides
+
+		if v, _ := cmd.Flags().GetString(generateCmdOverridesFileFlag); v != "" {
+			if err := parseAndApplyOverrideFile(v, generateCmdOverridesFileFlag, rawWorkload); err != nil {
+				return nil, err
 			}
-			workloadName := workload.Metadata["name"].(string)
-
-			// Apply image override
-			for containerName, container := range workload.Containers {
-				if container.Image == "." {
-					if v, _ := cmd.Flags().GetString(generateCmdImageFlag); v != "" {
-						container.Image = v
-						slog.Info(fmt.Sprintf("Set container image for container '%s' to %s from --%s", containerName, v, generateCmdImageFlag))
-						workload.Containers[containerName] = container
-					} else {
-						return errors.Errorf("failed to convert '%s' because container '%s' has no image and --image was not provided", arg, containerName)
-					}
+		}
+
+		// Now read, parse, and apply any override properties to the score files
+		if v, _ := cmd.Flags().GetStringArray(generateCmdOverridePropertyFlag); len(v) > 0 {
+			for _, overridePropertyEntry := range v {
+				if rawWorkload, err = parseAndApplyOverrideProperty(overridePropertyEntry, generateCmdOverridePropertyFlag, rawWorkload); err != nil {
+					return nil, err
 				}
 			}
+		}
 
-			var extras project.WorkloadExtras
-			if existing, ok := state.Workloads[workloadName]; ok && existing.Extras.InstanceSuffix != "" {
-				extras = existing.Extras
-			} else {
-				extrasBytes := make([]byte, 5)
-				_, _ = rand.Read(extrasBytes)
-				extras.InstanceSuffix = fmt.Sprintf("-%x", extrasBytes)
+		// Ensure transforms are applied (be a good citizen)
+		if changes, err := scoreschema.ApplyCommonUpgradeTransforms(rawWorkload); err != nil {
+			return nil, fmt.Errorf("failed to upgrade spec: %w", err)
+		} else if len(changes) > 0 {
+			for _, change := range changes {
+				slog.Info(fmt.Sprintf("Applying backwards compatible upgrade %s", change))
 			}
+		}
 
-			if state, err = state.WithWorkload(&workload, &arg, extras); err != nil {
-				return errors.Wrapf(err, "failed to add score file to project: %s", arg)
+		var workload scoretypes.Workload
+		if err = scoreschema.Validate(rawWorkload); err != nil {
+			return nil, errors.Wrapf(err, "invalid score file: %s", arg)
+		} else if err = scoreloader.MapSpec(&workload, rawWorkload); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode input score file: %s", arg)
+		}
+		workloadName := workload.Metadata["name"].(string)
+
+		// Apply image override
+		for containerName, container := range workload.Containers {
+			if container.Image == "." {
+				if v, _ := cmd.Flags().GetString(generateCmdImageFlag); v != "" {
+					container.Image = v
+					slog.Info(fmt.Sprintf("Set container image for container '%s' to %s from --%s", containerName, v, generateCmdImageFlag))
+					workload.Containers[containerName] = container
+				} else {
+					return nil, errors.Errorf("failed to convert '%s' because container '%s' has no image and --image was not provided", arg, containerName)
+				}
 			}
-			slog.Info("Added score file to project", "file", arg)
 		}
 
-		if len(validationErrors) > 0 {
-			return fmt.Errorf("validation failed:\n - %s", strings.Join(validationErrors, "\n - "))
+		var extras project.WorkloadExtras
+		if existing, ok := state.Workloads[workloadName]; ok && existing.Extras.InstanceSuffix != "" {
+			extras = existing.Extras
+		} else {
+			extrasBytes := make([]byte, 5)
+			_, _ = rand.Read(extrasBytes)
+			extras.InstanceSuffix = fmt.Sprintf("-%x", extrasBytes)
 		}
 
-		if len(state.Workloads) == 0 {
-			return errors.New("Project is empty, please add a score file")
+		if state, err = state.WithWorkload(&workload, &arg, extras); err != nil {
+			return nil, errors.Wrapf(err, "failed to add score file to project: %s", arg)
 		}
+		slog.Info("Added score file to project", "file", arg)
+	}
 
-		if state, err = state.WithPrimedResources(); err != nil {
-			return errors.Wrap(err, "failed to prime resources")
-		}
+	if len(validationErrors) > 0 {
+		return nil, fmt.Errorf("validation failed:\n - %s", strings.Join(validationErrors, "\n - "))
+	}
 
-		slog.Info("Primed resources", "#workloads", len(state.Workloads), "#resources", len(state.Resources))
+	if len(state.Workloads) == 0 {
+		return nil, errors.New("Project is empty, please add a score file")
+	}
 
-		localProvisioners, err := loader.LoadProvisionersFromDirectory(sd.Path, loader.DefaultSuffix)
-		if err != nil {
-			return errors.Wrapf(err, "failed to load provisioners")
-		}
-		slog.Info("Loaded provisioners", "#provisioners", len(localProvisioners))
+	if state, err = state.WithPrimedResources(); err != nil {
+		return nil, errors.Wrap(err, "failed to prime resources")
+	}
 
-		state, err = provisioners.ProvisionResources(context.Background(), state, localProvisioners, namespace)
-		if err != nil {
-			return errors.Wrap(err, "failed to provision resources")
+	slog.Info("Primed resources", "#workloads", len(state.Workloads), "#resources", len(state.Resources))
+
+	localProvisioners, err := loader.LoadProvisionersFromDirectory(sd.Path, loader.DefaultSuffix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load provisioners")
+	}
+	slog.Info("Loaded provisioners", "#provisioners", len(localProvisioners))
+
+	// When the state is not persisted, new resources with external provisioners are left pending so that they have no
+	// side effects.
+	var pending []framework.ResourceUid
+	if persist {
+		state, err = provisioners.ProvisionResourcesInParallel(cmd.Context(), state, localProvisioners, namespace, parallelism, provisionTimeout)
+	} else {
+		state, pending, err = provisioners.PreviewResourcesInParallel(cmd.Context(), state, localProvisioners, namespace, parallelism, provisionTimeout, isExternalProvisioner)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to provision resources")
+	}
+	for _, resUid := range pending {
+		slog.Warn(fmt.Sprintf("Resource '%s' is pending: its provisioner is only run by generate", resUid))
+	}
+
+	// Resources that are no longer referenced by any workload are only deprovisioned when the state is persisted, since
+	// deprovisioning may have side effects.
+	if unreferenced := provisioners.UnreferencedResourceUids(state); len(unreferenced) > 0 {
+		if noDeprovision, _ := cmd.Flags().GetBool(generateCmdNoDeprovisionFlag); !persist || noDeprovision {
+			slog.Info(fmt.Sprintf("Keeping %d resources that are no longer referenced by any workload", len(unreferenced)))
+		} else if state, err = provisioners.DeprovisionResources(cmd.Context(), state, unreferenced, localProvisioners, namespace, provisionTimeout); err != nil {
+			return nil, errors.Wrap(err, "failed to deprovision resources")
 		}
+	}
 
-		sd.State = *state
+	sd.State = *state
+	if persist {
 		if err := sd.Persist(); err != nil {
-			return errors.Wrap(err, "failed to persist state file")
+			return nil, errors.Wrap(err, "failed to persist state file")
 		}
 		slog.Info("Persisted state file")
+	}
 
-		outputManifests := make([]map[string]interface{}, 0)
-		resIds, _ := state.GetSortedResourceUids()
-		for _, id := range resIds {
-			res := state.Resources[id]
-			if len(res.Extras.Manifests) > 0 {
-				for _, manifest := range res.Extras.Manifests {
-					if p, ok := internal.FindFirstUnresolvedSecretRef("", manifest); ok {
-						return errors.Errorf("unresolved secret ref in manifest: %s", p)
-					}
-					mSig := buildManifestSignature(manifest)
-					outputManifests = slices.DeleteFunc(outputManifests, func(other map[string]interface{}) bool {
-						if buildManifestSignature(other) == mSig {
-							slog.Info(fmt.Sprintf("Overriding duplicate resource manifest %s", mSig))
-							return true
-						}
-						return false
-					})
-					outputManifests = append(outputManifests, manifest)
-				}
-				slog.Info(fmt.Sprintf("Wrote %d resource manifests to manifests buffer for resource '%s'", len(res.Extras.Manifests), id))
-			}
-		}
-
-		for workloadName := range state.Workloads {
-			manifests, err := convert.ConvertWorkload(state, workloadName)
+	outputManifests := make([]map[string]interface{}, 0)
+	// manifestSources tracks the workload name or resource uid that produced each manifest
+	manifestSources := make(map[string]string)
+	resIds, _ := state.GetSortedResourceUids()
+	for _, id := range resIds {
+		res := state.Resources[id]
+		resManifests := res.Extras.Manifests
+		if networkPolicies {
+			policies, err := convert.ConvertResourceNetworkPolicies(state, id)
 			if err != nil {
-				return errors.Wrapf(err, "workload: %s: failed to convert", workloadName)
+				return nil, errors.Wrapf(err, "resource '%s': failed to convert network policies", id)
 			}
-			for _, m := range manifests {
-				subOut := new(bytes.Buffer)
-				if err = internal.YamlSerializerInfo.Serializer.Encode(m.(runtime.Object), subOut); err != nil {
-					return errors.Wrapf(err, "workload: %s: failed to serialise manifest %s", workloadName, m.GetName())
+			resManifests = slices.Clone(resManifests)
+			for _, policy := range policies {
+				manifest, err := encodeObjectToManifest(policy)
+				if err != nil {
+					return nil, errors.Wrapf(err, "resource '%s': failed to serialise manifest %s", id, policy.GetName())
 				}
-				var intermediate map[string]interface{}
-				_ = yaml.Unmarshal(subOut.Bytes(), &intermediate)
-				if p, ok := internal.FindFirstUnresolvedSecretRef("", intermediate); ok {
-					return errors.Errorf("unresolved secret ref in manifest: %s", p)
+				resManifests = append(resManifests, manifest)
+			}
+		}
+		if len(resManifests) > 0 {
+			for _, manifest := range resManifests {
+				if p, ok := internal.FindFirstUnresolvedSecretRef("", manifest); ok {
+					return nil, errors.Errorf("unresolved secret ref in manifest: %s", p)
 				}
-				mSig := buildManifestSignature(intermediate)
+				mSig := buildManifestSignature(manifest)
 				outputManifests = slices.DeleteFunc(outputManifests, func(other map[string]interface{}) bool {
 					if buildManifestSignature(other) == mSig {
-						slog.Debug(fmt.Sprintf("Overriding duplicate resource manifest %s", mSig))
+						slog.Info(fmt.Sprintf("Overriding duplicate resource manifest %s", mSig))
 						return true
 					}
 					return false
 				})
-				outputManifests = append(outputManifests, intermediate)
+				outputManifests = append(outputManifests, manifest)
+				manifestSources[buildManifestSourceKey(manifest)] = string(id)
 			}
-			slog.Info(fmt.Sprintf("Wrote %d manifests to manifests buffer for workload '%s'", len(manifests), workloadName))
+			slog.Info(fmt.Sprintf("Wrote %d resource manifests to manifests buffer for resource '%s'", len(resManifests), id))
 		}
+	}
 
-		// Add namespace to manifests if specified
-		if namespace != "" {
-			for _, manifest := range outputManifests {
-				// Skip namespace resources
-				if kind, ok := manifest["kind"].(string); ok && kind == "Namespace" {
-					continue
+	for _, workloadName := range slices.Sorted(maps.Keys(state.Workloads)) {
+		manifests, err := convert.ConvertWorkload(state, workloadName, convert.SecurityProfile(securityProfile))
+		if err != nil {
+			return nil, errors.Wrapf(err, "workload: %s: failed to convert", workloadName)
+		}
+		if settings, _ := convert.DescribeSecuritySettings(state.Workloads[workloadName].Spec.Metadata, convert.SecurityProfile(securityProfile)); len(settings) > 0 {
+			slog.Info(fmt.Sprintf("Set security context of workload '%s': %s", workloadName, strings.Join(settings, ", ")))
+		}
+		if networkPolicies {
+			policy, err := convert.ConvertWorkloadNetworkPolicy(state, workloadName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "workload: %s: failed to convert network policy", workloadName)
+			}
+			manifests = append(manifests, policy)
+		}
+		for _, m := range manifests {
+			intermediate, err := encodeObjectToManifest(m.(runtime.Object))
+			if err != nil {
+				return nil, errors.Wrapf(err, "workload: %s: failed to serialise manifest %s", workloadName, m.GetName())
+			}
+			if p, ok := internal.FindFirstUnresolvedSecretRef("", intermediate); ok {
+				return nil, errors.Errorf("unresolved secret ref in manifest: %s", p)
+			}
+			mSig := buildManifestSignature(intermediate)
+			outputManifests = slices.DeleteFunc(outputManifests, func(other map[string]interface{}) bool {
+				if buildManifestSignature(other) == mSig {
+					slog.Debug(fmt.Sprintf("Overriding duplicate resource manifest %s", mSig))
+					return true
 				}
+				return false
+			})
+			outputManifests = append(outputManifests, intermediate)
+			manifestSources[buildManifestSourceKey(intermediate)] = workloadName
+		}
+		slog.Info(fmt.Sprintf("Wrote %d manifests to manifests buffer for workload '%s'", len(manifests), workloadName))
+	}
 
-				// Add namespace to metadata
-				if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
-					metadata["namespace"] = namespace
-				}
+	// Add namespace to manifests if specified
+	if namespace != "" {
+		for _, manifest := range outputManifests {
+			// Skip namespace resources
+			if kind, ok := manifest["kind"].(string); ok && kind == "Namespace" {
+				continue
 			}
 
-			// Generate namespace manifest if requested
-			if generateNamespace {
-				outputManifests = append([]map[string]interface{}{{
-					"apiVersion": "v1",
-					"kind":       "Namespace",
-					"metadata": map[string]interface{}{
-						"name": namespace,
-						"labels": map[string]interface{}{
-							"app.kubernetes.io/managed-by": "score-k8s",
-						},
-					},
-				}}, outputManifests...)
+			// Add namespace to metadata
+			if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+				metadata["namespace"] = namespace
 			}
 		}
 
-		for i, content := range state.Extras.PatchingTemplates {
-			slog.Info(fmt.Sprintf("Applying patching template %d", i+1))
-			outputManifests, err = patching.PatchServices(state, outputManifests, content, namespace)
-			if err != nil {
-				return fmt.Errorf("failed to patch template %d: %w", i+1, err)
-			}
+		// Generate namespace manifest if requested
+		if generateNamespace {
+			outputManifests = append([]map[string]interface{}{{
+				"apiVersion": "v1",
+				"kind":       "Namespace",
+				"metadata": map[string]interface{}{
+					"name": namespace,
+					"labels": map[string]interface{}{
+						"app.kubernetes.io/managed-by": "score-k8s",
+					},
+				},
+			}}, outputManifests...)
 		}
+	}
 
-		out := new(bytes.Buffer)
-		if outputFormat == outputFormatKyaml {
-			enc := &kyaml.Encoder{}
-			for _, manifest := range outputManifests {
-				// FromObject writes the "---" document separator itself.
-				if err := enc.FromObject(manifest, out); err != nil {
-					return fmt.Errorf("failed to encode manifest as kyaml: %w", err)
-				}
+	for i, content := range state.Extras.PatchingTemplates {
+		slog.Info(fmt.Sprintf("Applying patching template %d", i+1))
+		outputManifests, err = patching.PatchServices(state, outputManifests, content, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch template %d: %w", i+1, err)
+		}
+	}
+
+	if v, _ := cmd.Flags().GetStringArray(generateCmdPatchManifestsFlag); len(v) > 0 {
+		for _, entry := range v {
+			if outputManifests, err = parseAndApplyManifestPatch(entry, generateCmdPatchManifestsFlag, outputManifests); err != nil {
+				return nil, err
 			}
-		} else {
-			for _, manifest := range outputManifests {
-				out.WriteString("---\n")
-				enc := yaml.NewEncoder(out)
-				enc.SetIndent(2)
-				if err := enc.Encode(manifest); err != nil {
-					return fmt.Errorf("failed to encode manifest as yaml: %w", err)
-				}
+		}
+	}
+
+	// Sort after patching so that manifests added by the patches are in install order too.
+	sortManifestsByInstallOrder(outputManifests)
+
+	return &generatedManifests{state: state, manifests: outputManifests, sources: manifestSources, pending: pending}, nil
+}
+
+// encodeObjectToManifest serialises the typed Kubernetes object into a generic manifest.
+func encodeObjectToManifest(obj runtime.Object) (map[string]interface{}, error) {
+	buff := new(bytes.Buffer)
+	if err := internal.YamlSerializerInfo.Serializer.Encode(obj, buff); err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	_ = yaml.Unmarshal(buff.Bytes(), &out)
+	return out, nil
+}
+
+// encodeManifests encodes the manifests as a multi-document yaml or kyaml stream.
+func encodeManifests(manifests []map[string]interface{}, outputFormat string) (*bytes.Buffer, error) {
+	out := new(bytes.Buffer)
+	if outputFormat == outputFormatKyaml {
+		enc := &kyaml.Encoder{}
+		for _, manifest := range manifests {
+			// FromObject writes the "---" document separator itself.
+			if err := enc.FromObject(manifest, out); err != nil {
+				return nil, fmt.Errorf("failed to encode manifest as kyaml: %w", err)
 			}
 		}
-		v, _ := cmd.Flags().GetString(generateCmdOutputFlag)
-		if v == "" {
-			return fmt.Errorf("no output file specified")
-		} else if v == "-" {
-			_, _ = fmt.Fprint(cmd.OutOrStdout(), out.String())
-		} else if err := os.WriteFile(v+".tmp", out.Bytes(), 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
-		} else if err := os.Rename(v+".tmp", v); err != nil {
-			return fmt.Errorf("failed to complete writing output file: %w", err)
-		} else {
-			slog.Info(fmt.Sprintf("Wrote manifests to '%s'", v))
+	} else {
+		for _, manifest := range manifests {
+			out.WriteString("---\n")
+			enc := yaml.NewEncoder(out)
+			enc.SetIndent(2)
+			if err := enc.Encode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to encode manifest as yaml: %w", err)
+			}
 		}
-		return nil
-	},
+	}
+	return out, nil
 }
 
 func parseAndApplyOverrideFile(entry string, flagName string, spec map[string]interface{}) error {
@@ -397,6 +590,88 @@ func parseAndApplyOverrideProperty(entry string, flagName string, spec map[strin
 	}
 }
 
+// parseAndApplyManifestPatch applies a KIND/NAME/path=value patch to the matching manifests. The kind and name may
+// contain * wildcards and an empty value removes the path.
+func parseAndApplyManifestPatch(entry string, flagName string, manifests []map[string]interface{}) ([]map[string]interface{}, error) {
+	parts := strings.SplitN(entry, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("--%s '%s' is invalid, expected KIND/NAME/path=value", flagName, entry)
+	}
+	kindPattern, namePattern := parts[0], parts[1]
+	pathAndValue := strings.SplitN(parts[2], "=", 2)
+	if len(pathAndValue) != 2 || pathAndValue[0] == "" {
+		return nil, fmt.Errorf("--%s '%s' is invalid, expected a =-separated path and value", flagName, entry)
+	}
+	for _, pattern := range []string{kindPattern, namePattern} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("--%s '%s' is invalid, bad selector '%s': %w", flagName, entry, pattern, err)
+		}
+	}
+
+	isDelete := pathAndValue[1] == ""
+	var value interface{}
+	if !isDelete {
+		if err := yaml.Unmarshal([]byte(pathAndValue[1]), &value); err != nil {
+			return nil, fmt.Errorf("--%s '%s' is invalid, failed to unmarshal value as json: %w", flagName, entry, err)
+		}
+	}
+
+	out := slices.Clone(manifests)
+	var matched int
+	for i, manifest := range out {
+		kind, _ := manifest["kind"].(string)
+		metadata, _ := manifest["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		if ok, _ := path.Match(kindPattern, kind); !ok {
+			continue
+		} else if ok, _ := path.Match(namePattern, name); !ok {
+			continue
+		}
+		matched++
+		slog.Info(fmt.Sprintf("Patching '%s' in manifest %s", pathAndValue[0], buildManifestSignature(manifest)))
+		after, err := framework.OverridePathInMap(manifest, framework.ParseDotPathParts(pathAndValue[0]), isDelete, value)
+		if err != nil {
+			return nil, fmt.Errorf("--%s '%s' could not be applied to %s: %w", flagName, entry, buildManifestSignature(manifest), err)
+		}
+		out[i] = after
+	}
+	if matched == 0 {
+		return nil, fmt.Errorf("--%s '%s' did not match any manifests", flagName, entry)
+	}
+	return out, nil
+}
+
+// validateManifests validates each output manifest and reports the problems along with the workload or resource
+// that produced the manifest.
+func validateManifests(validator *validation.Validator, manifests []map[string]interface{}, manifestSources map[string]string, state *project.State) error {
+	var problems []string
+	for _, manifest := range manifests {
+		manifestProblems := validator.Validate(manifest)
+		if len(manifestProblems) == 0 {
+			continue
+		}
+		kind, _ := manifest["kind"].(string)
+		metadata, _ := manifest["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		origin := ""
+		if source, ok := manifestSources[buildManifestSourceKey(manifest)]; ok {
+			if _, isWorkload := state.Workloads[source]; isWorkload {
+				origin = fmt.Sprintf(" from workload '%s'", source)
+			} else {
+				origin = fmt.Sprintf(" from resource '%s'", source)
+			}
+		}
+		for _, p := range manifestProblems {
+			problems = append(problems, fmt.Sprintf("%s/%s%s: %s", kind, name, origin, p))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("manifest validation failed:\n - %s", strings.Join(problems, "\n - "))
+	}
+	slog.Info(fmt.Sprintf("Validated %d manifests", len(manifests)))
+	return nil
+}
+
 // buildManifestSignature builds a unique manifest signature for each manifest coming out of a resource. This is used
 // to deduplicate resource manifests when they share state.
 func buildManifestSignature(n map[string]interface{}) string {
@@ -408,14 +683,41 @@ func buildManifestSignature(n map[string]interface{}) string {
 	return fmt.Sprintf("%s/%s/%s/%s", apiVersion, kind, namespace, name)
 }
 
+// buildManifestSourceKey identifies a manifest without its namespace, since the namespace may be set after the manifest
+// was produced.
+func buildManifestSourceKey(n map[string]interface{}) string {
+	apiVersion, _ := n["apiVersion"].(string)
+	kind, _ := n["kind"].(string)
+	metadata, _ := n["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return fmt.Sprintf("%s/%s/%s", apiVersion, kind, name)
+}
+
+// addGenerateInputFlags adds the flags that control the generate pipeline. These are shared by the commands that run
+// the pipeline such as generate and diff.
+func addGenerateInputFlags(cmd *cobra.Command) {
+	cmd.Flags().String(generateCmdOverridesFileFlag, "", "An optional file of Score overrides to merge in")
+	cmd.Flags().StringArray(generateCmdOverridePropertyFlag, []string{}, "An optional set of path=key overrides to set or remove")
+	cmd.Flags().StringArray(generateCmdPatchManifestsFlag, []string{}, "An optional set of KIND/NAME/path=value patches to set or remove in the output manifests, * may be used as a wildcard")
+	cmd.Flags().StringP(generateCmdImageFlag, "i", "", "An optional container image to use for any container with image == '.'")
+	cmd.Flags().StringP(generateCmdNamespaceFlag, "n", "", "An optional namespace to set for all generated resources")
+	cmd.Flags().Bool(generateCmdGenerateNamespaceFlag, false, "If true, generate a namespace manifest. Requires --namespace to be set")
+	cmd.Flags().Int(generateCmdParallelismFlag, defaultProvisioningParallelism, "The maximum number of resources to provision at the same time")
+	cmd.Flags().Duration(generateCmdProvisionTimeoutFlag, 0, "An optional timeout, like 5m, after which provisioning or deprovisioning a resource is cancelled, 0 means no timeout")
+	cmd.Flags().Bool(generateCmdNetworkPoliciesFlag, false, "If true, generate NetworkPolicies that only allow ingress to workloads and resources from the workloads that depend on them, and from anywhere to the ports of route resources")
+	cmd.Flags().String(generateCmdSecurityProfileFlag, string(convert.SecurityProfileNone), "The security context settings to apply to the workload pods: 'restricted', 'baseline', or 'none'")
+}
+
 func init() {
 	generateCmd.Flags().StringP(generateCmdOutputFlag, "o", "manifests.yaml", "The output manifests file to write the manifests to")
-	generateCmd.Flags().String(generateCmdFormatFlag, outputFormatYaml, "The output format for the manifests: 'yaml' or 'kyaml'")
-	generateCmd.Flags().String(generateCmdOverridesFileFlag, "", "An optional file of Score overrides to merge in")
-	generateCmd.Flags().StringArray(generateCmdOverridePropertyFlag, []string{}, "An optional set of path=key overrides to set or remove")
-	generateCmd.Flags().StringP(generateCmdImageFlag, "i", "", "An optional container image to use for any container with image == '.'")
-	generateCmd.Flags().StringP(generateCmdNamespaceFlag, "n", "", "An optional namespace to set for all generated resources")
-	generateCmd.Flags().Bool(generateCmdGenerateNamespaceFlag, false, "If true, generate a namespace manifest. Requires --namespace to be set")
+	generateCmd.Flags().String(generateCmdOutputDirFlag, "", "An optional directory to write one file per manifest and a kustomization.yaml to, instead of --output")
+	generateCmd.Flags().String(generateCmdFormatFlag, outputFormatYaml, "The output format for the manifests: 'yaml', 'kyaml', or 'helm-chart' (requires --output-dir)")
+	generateCmd.Flags().String(generateCmdChartVersionFlag, "0.1.0", "The chart version to set when using --format=helm-chart")
+	generateCmd.Flags().Bool(generateCmdValidateFlag, false, "If true, validate the output manifests offline against the bundled Kubernetes API schemas")
+	generateCmd.Flags().String(generateCmdKubeVersionFlag, validation.DefaultKubeVersion, "The Kubernetes version to validate against when using --validate")
+	generateCmd.Flags().Bool(generateCmdNoDeprovisionFlag, false, "If true, keep resources in the state that are no longer referenced by any workload instead of deprovisioning them")
+	generateCmd.Flags().String(generateCmdCrdSchemasFlag, "", "An optional directory of CustomResourceDefinition files to validate custom resources against when using --validate")
+	addGenerateInputFlags(generateCmd)
 
 	rootCmd.AddCommand(generateCmd)
 }
@@ -18,11 +18,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/score-spec/score-go/framework"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -322,6 +325,108 @@ resources:
 	assert.Contains(t, string(raw), string(raw2))
 }
 
+func TestGenerateIsDeterministic(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://dummy
+  type: dummy
+  manifests: |
+    - apiVersion: v1
+      kind: Service
+      metadata:
+        name: {{ .Id }}-svc
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: {{ .Id }}-cfg
+    - apiVersion: v1
+      kind: Secret
+      metadata:
+        name: {{ .Id }}-secret
+`), 0644))
+	for _, name := range []string{"zulu", "alpha", "mike"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(td, name+".yaml"), []byte(fmt.Sprintf(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: %[1]s
+containers:
+  main:
+    image: nginx:latest
+service:
+  ports:
+    web:
+      port: 8080
+    admin:
+      port: 9000
+    metrics:
+      port: 9090
+resources:
+  res-%[1]s:
+    type: dummy
+`, name)), 0644))
+	}
+
+	var outputs []string
+	for i := 0; i < 2; i++ {
+		_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "--namespace", "test-ns", "--generate-namespace", "--", "zulu.yaml", "alpha.yaml", "mike.yaml",
+		})
+		require.NoError(t, err)
+		raw, err := os.ReadFile(filepath.Join(td, "manifests.yaml"))
+		require.NoError(t, err)
+		outputs = append(outputs, string(raw))
+	}
+	assert.Equal(t, outputs[0], outputs[1])
+
+	var identities []string
+	dec := yaml.NewDecoder(strings.NewReader(outputs[0]))
+	for {
+		var manifest map[string]interface{}
+		if err := dec.Decode(&manifest); err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		identities = append(identities, fmt.Sprintf("%s/%s", manifest["kind"], manifest["metadata"].(map[string]interface{})["name"]))
+	}
+	assert.Equal(t, []string{
+		"Namespace/test-ns",
+		"ServiceAccount/alpha",
+		"ServiceAccount/mike",
+		"ServiceAccount/zulu",
+		"Secret/alpha.res-alpha-secret",
+		"Secret/mike.res-mike-secret",
+		"Secret/zulu.res-zulu-secret",
+		"ConfigMap/alpha.res-alpha-cfg",
+		"ConfigMap/mike.res-mike-cfg",
+		"ConfigMap/zulu.res-zulu-cfg",
+		"Service/alpha.res-alpha-svc",
+		"Service/mike.res-mike-svc",
+		"Service/zulu.res-zulu-svc",
+		"Service/alpha",
+		"Service/mike",
+		"Service/zulu",
+		"Deployment/alpha",
+		"Deployment/mike",
+		"Deployment/zulu",
+	}, identities)
+	assert.Contains(t, outputs[0], `  ports:
+    - name: admin
+      port: 9000
+      protocol: TCP
+      targetPort: 9000
+    - name: metrics
+      port: 9090
+      protocol: TCP
+      targetPort: 9090
+    - name: web
+      port: 8080
+      protocol: TCP
+      targetPort: 8080
+`)
+}
+
 func TestGenerateMultipleSpecsWithImage(t *testing.T) {
 	td := changeToTempDir(t)
 	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
@@ -450,7 +555,8 @@ containers:
 	t.Log(string(rawManifests))
 	assert.NotContains(t, string(rawManifests), "my-secret")
 	assert.Contains(t, string(rawManifests), "other-secret")
-	assert.Equal(t, strings.Count(string(rawManifests), "custom.annotation/key: something"), 1)
+	// patch-1 annotates each generated manifest: the ServiceAccount and the Deployment
+	assert.Equal(t, strings.Count(string(rawManifests), "custom.annotation/key: something"), 2)
 }
 
 func TestPatchTemplatesStatefulSet(t *testing.T) {
@@ -507,6 +613,30 @@ containers:
 	require.NoError(t, err)
 	t.Log(string(rawManifests))
 	assert.Equal(t, string(rawManifests), fmt.Sprintf(`---
+apiVersion: v1
+automountServiceAccountToken: false
+kind: ServiceAccount
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example%[1]s
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: example-headless-svc
+spec:
+  clusterIP: None
+  ports:
+    - name: "99"
+      port: 99
+  selector:
+    app.kubernetes.io/instance: example%[1]s
+---
 apiVersion: apps/v1
 kind: StatefulSet
 metadata:
@@ -531,24 +661,296 @@ spec:
         app.kubernetes.io/managed-by: score-k8s
         app.kubernetes.io/name: example
     spec:
+      automountServiceAccountToken: false
       containers:
         - image: foo
           name: hello
           resources: {}
+      serviceAccountName: example
 status: {}
----
-apiVersion: v1
-kind: Service
+`, sd.State.Workloads["example"].Extras.InstanceSuffix))
+}
+
+func TestGenerateWithPatchManifests(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
 metadata:
-  name: example-headless-svc
-spec:
-  clusterIP: None
+  name: example
+containers:
+  main:
+    image: nginx:latest
+service:
   ports:
-    - name: "99"
-      port: 99
-  selector:
-    app.kubernetes.io/instance: example%[1]s
-`, sd.State.Workloads["example"].Extras.InstanceSuffix))
+    web:
+      port: 8080
+`), 0644))
+
+	t.Run("set and delete", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "score.yaml",
+			"--patch-manifests", "*/*/metadata.annotations.team=platform",
+			"--patch-manifests", "Deployment/ex*/spec.replicas=4",
+			"--patch-manifests", "Service/example/metadata.labels=",
+		})
+		require.NoError(t, err)
+		raw, err := os.ReadFile(filepath.Join(td, "manifests.yaml"))
+		require.NoError(t, err)
+
+		manifests := make(map[string]map[string]interface{})
+		dec := yaml.NewDecoder(strings.NewReader(string(raw)))
+		for {
+			var manifest map[string]interface{}
+			if err := dec.Decode(&manifest); err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			manifests[manifest["kind"].(string)] = manifest
+		}
+		require.Len(t, manifests, 3)
+		for _, manifest := range manifests {
+			assert.Equal(t, "platform", manifest["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["team"])
+		}
+		assert.Equal(t, 4, manifests["Deployment"]["spec"].(map[string]interface{})["replicas"])
+		assert.NotContains(t, manifests["Service"]["metadata"], "labels")
+		assert.Contains(t, manifests["Deployment"]["metadata"], "labels")
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "score.yaml", "--patch-manifests", "StatefulSet/*/spec.replicas=4",
+		})
+		assert.EqualError(t, err, "--patch-manifests 'StatefulSet/*/spec.replicas=4' did not match any manifests")
+	})
+
+	t.Run("invalid syntax", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "score.yaml", "--patch-manifests", "Deployment/spec.replicas=4",
+		})
+		assert.EqualError(t, err, "--patch-manifests 'Deployment/spec.replicas=4' is invalid, expected KIND/NAME/path=value")
+		_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "score.yaml", "--patch-manifests", "Deployment/example/spec.replicas",
+		})
+		assert.EqualError(t, err, "--patch-manifests 'Deployment/example/spec.replicas' is invalid, expected a =-separated path and value")
+	})
+}
+
+func TestGenerateWithValidate(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://dummy
+  type: dummy
+  manifests: |
+    - apiVersion: policy/v1
+      kind: PodDisruptionBudget
+      metadata:
+        name: pdb
+      spec:
+        minAvailablee: 1
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx:latest
+service:
+  ports:
+    web:
+      port: 8080
+`), 0644))
+
+	t.Run("valid", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--validate", "--kube-version", "1.30"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid workload manifest", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "score.yaml", "--validate", "--patch-manifests", "Deployment/example/spec.replicas=many",
+		})
+		assert.EqualError(t, err, "manifest validation failed:\n - Deployment/example from workload 'example': spec.replicas: expected integer, but got string")
+	})
+
+	t.Run("invalid resource manifest", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx:latest
+resources:
+  thing:
+    type: dummy
+`), 0644))
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--validate"})
+		assert.EqualError(t, err, "manifest validation failed:\n - PodDisruptionBudget/pdb from resource 'dummy.default#example.thing': spec: additionalProperties 'minAvailablee' not allowed")
+	})
+
+	t.Run("requires validate", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--kube-version", "1.30"})
+		assert.EqualError(t, err, "--validate flag is required when using --kube-version or --crd-schemas")
+	})
+
+	t.Run("bad kube version", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--validate", "--kube-version", "latest"})
+		assert.EqualError(t, err, "failed to set up --validate: invalid kubernetes version 'latest', expected a version like 1.30")
+	})
+}
+
+func TestGenerateWithNetworkPolicies(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "api.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: api
+containers:
+  main:
+    image: nginx
+service:
+  ports:
+    http:
+      port: 80
+      targetPort: 8080
+resources:
+  db:
+    type: postgres
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "web.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: web
+containers:
+  main:
+    image: nginx
+    variables:
+      API_URL: http://${resources.api.hostname}:${resources.api.port}
+resources:
+  api:
+    type: service-port
+    params:
+      workload: api
+      port: http
+`), 0644))
+
+	policies := func() map[string]interface{} {
+		raw, err := os.ReadFile(filepath.Join(td, "manifests.yaml"))
+		require.NoError(t, err)
+		out := make(map[string]interface{})
+		dec := yaml.NewDecoder(strings.NewReader(string(raw)))
+		for {
+			var manifest map[string]interface{}
+			if err := dec.Decode(&manifest); err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if manifest["kind"] == "NetworkPolicy" {
+				out[manifest["metadata"].(map[string]interface{})["name"].(string)] = manifest["spec"]
+			}
+		}
+		return out
+	}
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "api.yaml", "web.yaml"})
+	require.NoError(t, err)
+	assert.Empty(t, policies())
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "api.yaml", "web.yaml", "--network-policies", "--validate"})
+	require.NoError(t, err)
+	sd, _, err := project.LoadStateDirectory(".")
+	require.NoError(t, err)
+	out := policies()
+	assert.Len(t, out, 3)
+	assert.Contains(t, out, "web")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"from":  []interface{}{map[string]interface{}{"podSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app.kubernetes.io/instance": "web" + sd.State.Workloads["web"].Extras.InstanceSuffix}}}},
+		"ports": []interface{}{map[string]interface{}{"port": 8080, "protocol": "TCP"}},
+	}}, out["api"].(map[string]interface{})["ingress"])
+	for name, spec := range out {
+		if strings.HasPrefix(name, "pg-api-") {
+			assert.Equal(t, []interface{}{map[string]interface{}{"port": 5432, "protocol": "TCP"}}, spec.(map[string]interface{})["ingress"].([]interface{})[0].(map[string]interface{})["ports"])
+		}
+	}
+}
+
+func TestGenerateDeprovisionsUnreferencedResources(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://dummy
+  type: dummy
+  shared: |
+    {{ .Uid }}: true
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: {{ .Id }}
+  deprovision: |
+    last-deprovisioned: {{ .Uid }}
+`), 0644))
+	writeScore := func(resources ...string) {
+		content := `
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+`
+		for _, r := range resources {
+			content += "  " + r + ":\n    type: dummy\n"
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(content), 0644))
+	}
+	loadState := func() *project.State {
+		sd, ok, err := project.LoadStateDirectory(".")
+		require.NoError(t, err)
+		require.True(t, ok)
+		return &sd.State
+	}
+
+	writeScore("one", "two")
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml"})
+	require.NoError(t, err)
+	assert.Len(t, loadState().Resources, 2)
+
+	writeScore("one")
+
+	t.Run("no deprovision", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--no-deprovision"})
+		require.NoError(t, err)
+		state := loadState()
+		assert.Contains(t, state.Resources, framework.ResourceUid("dummy.default#example.two"))
+		assert.Equal(t, map[string]interface{}{
+			"dummy.default#example.one": true,
+			"dummy.default#example.two": true,
+		}, state.SharedState)
+		raw, err := os.ReadFile(filepath.Join(td, "manifests.yaml"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "name: example.two")
+	})
+
+	t.Run("deprovision", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate"})
+		require.NoError(t, err)
+		state := loadState()
+		assert.Equal(t, []framework.ResourceUid{"dummy.default#example.one"}, slices.Sorted(maps.Keys(state.Resources)))
+		assert.Equal(t, map[string]interface{}{
+			"dummy.default#example.one": true,
+			"last-deprovisioned":        "dummy.default#example.two",
+		}, state.SharedState)
+	})
 }
 
 func TestGenerateWithKyamlFormat(t *testing.T) {
@@ -619,3 +1021,223 @@ containers:
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), `invalid --format value "json"`)
 }
+
+func TestGenerateWithSecurityProfile(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx:latest`), 0644))
+
+	t.Run("restricted", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--security-profile", "restricted", "--validate"})
+		require.NoError(t, err)
+		raw, err := os.ReadFile(filepath.Join(td, "manifests.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), `
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault
+`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--security-profile", "strict"})
+		assert.EqualError(t, err, `invalid --security-profile value "strict", expected "restricted", "baseline", or "none"`)
+	})
+}
+
+func TestGenerateWithOutputDir(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://dummy
+  type: dummy
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: cfg-{{ .Id }}
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx:latest
+service:
+  ports:
+    web:
+      port: 80
+resources:
+  res:
+    type: dummy
+`), 0644))
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{
+		"generate", "--output-dir", "out", "--namespace", "test-ns", "--generate-namespace", "--", "score.yaml",
+	})
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(filepath.Join(td, "out", "kustomization.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - namespace-test-ns.yaml
+  - example/serviceaccount-example.yaml
+  - dummy.default_example.res/configmap-cfg-example.res.yaml
+  - example/service-example.yaml
+  - example/deployment-example.yaml
+`, string(raw))
+	raw, err = os.ReadFile(filepath.Join(td, "out", "example", "service-example.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "kind: Service\n")
+	assert.Contains(t, string(raw), "namespace: test-ns\n")
+	_, err = os.Stat(filepath.Join(td, "manifests.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	t.Run("stale files are removed", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx:latest
+`), 0644))
+		_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "--output-dir", "out", "--", "score.yaml",
+		})
+		require.NoError(t, err)
+		raw, err := os.ReadFile(filepath.Join(td, "out", "kustomization.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - example/serviceaccount-example.yaml
+  - example/deployment-example.yaml
+`, string(raw))
+		for _, p := range []string{"namespace-test-ns.yaml", "dummy.default_example.res", "example/service-example.yaml"} {
+			_, err = os.Stat(filepath.Join(td, "out", p))
+			assert.ErrorIs(t, err, os.ErrNotExist, p)
+		}
+	})
+
+	t.Run("output and output-dir are exclusive", func(t *testing.T) {
+		_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "--output-dir", "out", "--output", "manifests.yaml",
+		})
+		assert.EqualError(t, err, "cannot use --output and --output-dir together")
+	})
+}
+
+func TestGenerateWithHelmChartFormat(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx:latest
+    resources:
+      limits:
+        memory: 128Mi
+resources:
+  res:
+    type: dummy
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://dummy
+  type: dummy
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: cfg
+      data:
+        config.tpl: '{{ "hello {{ .Name }}" }}'
+`), 0644))
+
+	t.Run("requires output dir", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "--format", "helm-chart", "--", "score.yaml",
+		})
+		assert.EqualError(t, err, "--output-dir is required when using --format=helm-chart")
+	})
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{
+		"generate", "--format", "helm-chart", "--output-dir", "my-chart", "--chart-version", "1.2.3", "--", "score.yaml",
+	})
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(filepath.Join(td, "my-chart", "Chart.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v2
+name: my-chart
+description: A Helm chart generated by score-k8s
+type: application
+version: 1.2.3
+`, string(raw))
+
+	raw, err = os.ReadFile(filepath.Join(td, "my-chart", "values.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `workloads:
+  example:
+    containers:
+      main:
+        image: nginx:latest
+        resources:
+          limits:
+            memory: 128Mi
+    replicas: 1
+`, string(raw))
+
+	raw, err = os.ReadFile(filepath.Join(td, "my-chart", "templates", "example", "deployment-example.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `replicas: {{ index .Values "workloads" "example" "replicas" | toJson }}`)
+	assert.Contains(t, string(raw), `image: {{ index .Values "workloads" "example" "containers" "main" "image" | toJson }}`)
+	assert.Contains(t, string(raw), `resources: {{ index .Values "workloads" "example" "containers" "main" "resources" | toJson }}`)
+
+	raw, err = os.ReadFile(filepath.Join(td, "my-chart", "templates", "dummy.default_example.res", "configmap-cfg.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `config.tpl: hello {{ "{{" }} .Name }}`)
+
+	t.Run("only removes templates that are no longer generated", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(td, "my-chart", "templates", "extra.yaml"), []byte("# hand-written\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(td, "my-chart", "templates", "example", "extra.yaml"), []byte("# hand-written\n"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx:latest
+`), 0644))
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+			"generate", "--format", "helm-chart", "--output-dir", "my-chart", "--", "score.yaml",
+		})
+		require.NoError(t, err)
+
+		raw, err := os.ReadFile(filepath.Join(td, "my-chart", ".score-k8s-templates.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, `templates:
+  - example/serviceaccount-example.yaml
+  - example/deployment-example.yaml
+`, string(raw))
+		assert.NoDirExists(t, filepath.Join(td, "my-chart", "templates", "dummy.default_example.res"))
+		assert.FileExists(t, filepath.Join(td, "my-chart", "templates", "extra.yaml"))
+		assert.FileExists(t, filepath.Join(td, "my-chart", "templates", "example", "extra.yaml"))
+		assert.FileExists(t, filepath.Join(td, "my-chart", "templates", "example", "deployment-example.yaml"))
+	})
+}
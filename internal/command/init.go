@@ -22,21 +22,28 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/score-spec/score-go/framework"
-	"github.com/score-spec/score-go/uriget"
 	"github.com/spf13/cobra"
 
+	"github.com/score-spec/score-k8s/internal/convert"
 	"github.com/score-spec/score-k8s/internal/patching"
 	"github.com/score-spec/score-k8s/internal/project"
 	_default "github.com/score-spec/score-k8s/internal/provisioners/default"
 	"github.com/score-spec/score-k8s/internal/provisioners/loader"
+	"github.com/score-spec/score-k8s/internal/signature"
 )
 
 const (
-	initCmdFileFlag                  = "file"
-	initCmdFileNoSampleFlag          = "no-sample"
-	initCmdProvisionerFlag           = "provisioners"
-	initCmdPatchTemplateFlag         = "patch-templates"
-	initCmdNoDefaultProvisionersFlag = "no-default-provisioners"
+	initCmdFileFlag                     = "file"
+	initCmdFileNoSampleFlag             = "no-sample"
+	initCmdProvisionerFlag              = "provisioners"
+	initCmdPatchTemplateFlag            = "patch-templates"
+	initCmdNoDefaultProvisionersFlag    = "no-default-provisioners"
+	initCmdFrozenFlag                   = "frozen"
+	initCmdUpgradeFlag                  = "upgrade"
+	initCmdTrustedKeyFlag               = "trusted-key"
+	initCmdAllowUnsignedFlag            = "allow-unsigned"
+	initCmdDefaultPdbMinAvailableFlag   = "default-pdb-min-available"
+	initCmdDefaultPdbMaxUnavailableFlag = "default-pdb-max-unavailable"
 
 	DefaultScoreFileContent = `# Score provides a developer-centric and platform-agnostic
 # Workload specification to improve developer productivity and experience.
@@ -99,6 +106,21 @@ Golang text/template and should output a yaml/json encoded array of patches. Eac
 (set or delete), 'path' (a dot-separated json path), a 'value' if the 'op' == 'set', and an optional 'description' for 
 showing in the logs. The template has access to '.Manifests' and '.Workloads'. Note, if you are deleting manifests or
 keys, these operations should be done last wherever possible to avoid breaking the patch.
+
+The source uri, git commit or OCI manifest digest, and SHA-256 checksum of each provisioners file and patch template
+fetched over http(s), git, or OCI are recorded in '.score-k8s/lock.yaml'. Local files and stdin are not locked. When a source in the lock file is fetched again and its content
+has changed, init fails unless --upgrade is set to deliberately update the lock file. With --frozen, init also fails
+when a source is not in the lock file and the lock file is never written.
+
+When trusted ed25519 public keys are configured with --trusted-key or as PEM files in '.score-k8s/trusted-keys', each
+provisioners file fetched from a remote source must have a valid signature by one of the keys. The signature is a
+base64 encoded detached signature in a file next to the provisioners file with a '.sig' suffix, or for OCI artifacts a
+referrer with the artifact type 'application/vnd.score.signature.v1'. Unsigned or badly signed files are refused unless
+--allow-unsigned is set.
+
+A project-wide default PodDisruptionBudget can be set with --default-pdb-min-available or --default-pdb-max-unavailable.
+It is generated for each Deployment or StatefulSet workload with more than one replica that does not set its own
+disruption budget annotation. Setting the flag to an empty value removes the default.
 `,
 	Example: `
   # Initialise a new score-k8s project
@@ -110,6 +132,18 @@ keys, these operations should be done last wherever possible to avoid breaking t
   # Optionally loading in provisoners from a remote url
   score-k8s init --provisioners https://raw.githubusercontent.com/user/repo/main/example.yaml
 
+  # Fail if the content of the provisioners differs from the lock file or is not in the lock file
+  score-k8s init --frozen --provisioners https://raw.githubusercontent.com/user/repo/main/example.yaml
+
+  # Update the lock file with the latest content of the provisioners
+  score-k8s init --upgrade --provisioners https://raw.githubusercontent.com/user/repo/main/example.yaml
+
+  # Only install provisioners that are signed by a trusted key
+  score-k8s init --trusted-key ./platform-team.pem --provisioners oci://ghcr.io/my-org/provisioners:v1#custom.provisioners.yaml
+
+  # Keep at least half of the replicas of each workload with more than one replica available during disruptions
+  score-k8s init --default-pdb-min-available 50%
+
   # Optionally adding a couple of patching templates, see below for an example of a patching template.
   score-k8s init --patch-templates ./patching.tpl --patch-templates https://raw.githubusercontent.com/user/repo/main/example.tpl
   patching.tpl: |
@@ -136,15 +170,28 @@ URI Retrieval:
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
 
+		lockMode := lockModeDefault
+		if v, _ := cmd.Flags().GetBool(initCmdFrozenFlag); v {
+			lockMode = lockModeFrozen
+		} else if v, _ := cmd.Flags().GetBool(initCmdUpgradeFlag); v {
+			lockMode = lockModeUpgrade
+		}
+		lock, lockExists, err := project.LoadLockFile(project.DefaultRelativeStateDirectory)
+		if err != nil {
+			return errors.Wrap(err, "failed to load lock file")
+		}
+
 		initCmdPatchingFiles, _ := cmd.Flags().GetStringArray(initCmdPatchTemplateFlag)
 
 		var templates []string
+		var lockedTemplates []project.LockedFile
 		for _, u := range initCmdPatchingFiles {
 			slog.Info(fmt.Sprintf("Fetching patch template from %s", u))
-			files, err := uriget.GetFiles(cmd.Context(), u)
+			files, locked, err := fetchLockedFiles(cmd.Context(), u, lock.PatchTemplates, lockMode)
 			if err != nil {
 				return fmt.Errorf("error fetching patch template from %s: %w", u, err)
 			}
+			lockedTemplates = append(lockedTemplates, locked...)
 			for _, f := range files {
 				if err = patching.ValidatePatchTemplate(string(f.Content)); err != nil {
 					return fmt.Errorf("error parsing patch template from %s: %w", f.URI, err)
@@ -153,6 +200,20 @@ URI Retrieval:
 			}
 		}
 
+		var defaultDisruptionBudget *project.DisruptionBudget
+		setDefaultDisruptionBudget := cmd.Flags().Changed(initCmdDefaultPdbMinAvailableFlag) || cmd.Flags().Changed(initCmdDefaultPdbMaxUnavailableFlag)
+		if setDefaultDisruptionBudget {
+			budget := project.DisruptionBudget{}
+			budget.MinAvailable, _ = cmd.Flags().GetString(initCmdDefaultPdbMinAvailableFlag)
+			budget.MaxUnavailable, _ = cmd.Flags().GetString(initCmdDefaultPdbMaxUnavailableFlag)
+			if budget != (project.DisruptionBudget{}) {
+				if err := convert.ValidateDisruptionBudget(budget); err != nil {
+					return errors.Wrap(err, "invalid default disruption budget")
+				}
+				defaultDisruptionBudget = &budget
+			}
+		}
+
 		sd, ok, err := project.LoadStateDirectory(".")
 		if err != nil {
 			return errors.Wrap(err, "failed to load existing state directory")
@@ -164,6 +225,11 @@ URI Retrieval:
 				sd.State.Extras.PatchingTemplates = templates
 				hasChanges = true
 			}
+			if setDefaultDisruptionBudget {
+				slog.Info("Updating default disruption budget..")
+				sd.State.Extras.DefaultDisruptionBudget = defaultDisruptionBudget
+				hasChanges = true
+			}
 			if hasChanges {
 				if err := sd.Persist(); err != nil {
 					return fmt.Errorf("failed to persist state file: %w", err)
@@ -174,7 +240,7 @@ URI Retrieval:
 			sd = &project.StateDirectory{
 				Path: project.DefaultRelativeStateDirectory,
 				State: project.State{
-					Extras:      project.StateExtras{PatchingTemplates: templates},
+					Extras:      project.StateExtras{PatchingTemplates: templates, DefaultDisruptionBudget: defaultDisruptionBudget},
 					Workloads:   map[string]framework.ScoreWorkloadState[project.WorkloadExtras]{},
 					Resources:   map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{},
 					SharedState: map[string]interface{}{},
@@ -224,12 +290,23 @@ URI Retrieval:
 			slog.Info("Skipping creation of initial Score file since it already exists", "file", initCmdScoreFile)
 		}
 
-		if v, _ := cmd.Flags().GetStringArray(initCmdProvisionerFlag); len(v) > 0 {
-			for i, vi := range v {
-				files, err := uriget.GetFiles(cmd.Context(), vi)
+		initCmdProvisionerFiles, _ := cmd.Flags().GetStringArray(initCmdProvisionerFlag)
+		if len(initCmdProvisionerFiles) > 0 {
+			trustedKeyFiles, _ := cmd.Flags().GetStringArray(initCmdTrustedKeyFlag)
+			trustedKeys, err := signature.LoadTrustedKeys(trustedKeyFiles, filepath.Join(sd.Path, trustedKeysDirectory))
+			if err != nil {
+				return fmt.Errorf("failed to load trusted keys: %w", err)
+			}
+			allowUnsigned, _ := cmd.Flags().GetBool(initCmdAllowUnsignedFlag)
+			for i, vi := range initCmdProvisionerFiles {
+				files, locked, err := fetchLockedFiles(cmd.Context(), vi, lock.Provisioners, lockMode)
 				if err != nil {
 					return fmt.Errorf("failed to load provisioner %d: %w", i+1, err)
 				}
+				if files, err = verifyProvisionerFiles(cmd.Context(), vi, files, trustedKeys, allowUnsigned); err != nil {
+					return fmt.Errorf("failed to load provisioner %d: %w", i+1, err)
+				}
+				lock.Provisioners = replaceLockedFiles(lock.Provisioners, vi, locked)
 				for _, f := range files {
 					saveFilename := f.URI
 					if saveFilename == "-" {
@@ -248,6 +325,18 @@ URI Retrieval:
 			slog.Debug(fmt.Sprintf("Successfully loaded %d resource provisioners", len(provs)))
 		}
 
+		if len(initCmdPatchingFiles) > 0 {
+			lock.PatchTemplates = lockedTemplates
+		}
+		// The lock file is only written when it already exists or when a remote source was locked.
+		writeLock := lockExists || len(lock.Provisioners) > 0 || len(lock.PatchTemplates) > 0
+		if lockMode != lockModeFrozen && writeLock && (len(initCmdPatchingFiles) > 0 || len(initCmdProvisionerFiles) > 0) {
+			if err := lock.Persist(sd.Path); err != nil {
+				return errors.Wrap(err, "failed to persist lock file")
+			}
+			slog.Info("Updated lock file", "file", filepath.Join(sd.Path, project.LockFileName))
+		}
+
 		slog.Info("Read more about the Score specification at https://docs.score.dev/docs/")
 
 		return nil
@@ -260,5 +349,13 @@ func init() {
 	initCmd.Flags().StringArray(initCmdProvisionerFlag, nil, "Provisioner files to install. May be specified multiple times. Supports URI retrieval.")
 	initCmd.Flags().StringArray(initCmdPatchTemplateFlag, nil, "Patching template files to include. May be specified multiple times. Supports URI retrieval.")
 	initCmd.Flags().Bool(initCmdNoDefaultProvisionersFlag, false, "Disable generation of the default provisioners file")
+	initCmd.Flags().Bool(initCmdFrozenFlag, false, "Fail if fetched provisioners or patch templates are not in the lock file or their content changed")
+	initCmd.Flags().Bool(initCmdUpgradeFlag, false, "Update the lock file with the content of the fetched provisioners and patch templates")
+	initCmd.MarkFlagsMutuallyExclusive(initCmdFrozenFlag, initCmdUpgradeFlag)
+	initCmd.Flags().StringArray(initCmdTrustedKeyFlag, nil, "PEM encoded ed25519 public key files to verify the signatures of remote provisioner files against. May be specified multiple times.")
+	initCmd.Flags().Bool(initCmdAllowUnsignedFlag, false, "Install remote provisioner files without a valid signature by a trusted key")
+	initCmd.Flags().String(initCmdDefaultPdbMinAvailableFlag, "", "Default minimum number or percentage of available pods for workloads with more than one replica")
+	initCmd.Flags().String(initCmdDefaultPdbMaxUnavailableFlag, "", "Default maximum number or percentage of unavailable pods for workloads with more than one replica")
+	initCmd.MarkFlagsMutuallyExclusive(initCmdDefaultPdbMinAvailableFlag, initCmdDefaultPdbMaxUnavailableFlag)
 	rootCmd.AddCommand(initCmd)
 }
@@ -16,20 +16,31 @@ package command
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
 
 	"github.com/score-spec/score-go/framework"
+	"github.com/score-spec/score-go/uriget"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/score-spec/score-k8s/internal/project"
 	"github.com/score-spec/score-k8s/internal/provisioners"
 	"github.com/score-spec/score-k8s/internal/provisioners/loader"
+	"github.com/score-spec/score-k8s/internal/signature"
 )
 
 func TestInitNominal(t *testing.T) {
@@ -203,3 +214,326 @@ func TestInitWithPatchingFiles(t *testing.T) {
 		assert.Error(t, err, "failed to parse template: template: :1: function \"what\" not defined")
 	})
 }
+
+func TestInitWithDefaultDisruptionBudget(t *testing.T) {
+	td := t.TempDir()
+	wd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(td))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	t.Run("new", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--default-pdb-min-available", "50%"})
+		assert.NoError(t, err)
+		sd, ok, err := project.LoadStateDirectory(".")
+		assert.NoError(t, err)
+		if assert.True(t, ok) {
+			assert.Equal(t, &project.DisruptionBudget{MinAvailable: "50%"}, sd.State.Extras.DefaultDisruptionBudget)
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+		assert.NoError(t, err)
+		sd, _, err := project.LoadStateDirectory(".")
+		assert.NoError(t, err)
+		assert.Equal(t, &project.DisruptionBudget{MinAvailable: "50%"}, sd.State.Extras.DefaultDisruptionBudget)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--default-pdb-max-unavailable", "1"})
+		assert.NoError(t, err)
+		sd, _, err := project.LoadStateDirectory(".")
+		assert.NoError(t, err)
+		assert.Equal(t, &project.DisruptionBudget{MaxUnavailable: "1"}, sd.State.Extras.DefaultDisruptionBudget)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--default-pdb-max-unavailable", ""})
+		assert.NoError(t, err)
+		sd, _, err := project.LoadStateDirectory(".")
+		assert.NoError(t, err)
+		assert.Nil(t, sd.State.Extras.DefaultDisruptionBudget)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--default-pdb-min-available", "half"})
+		assert.EqualError(t, err, "invalid default disruption budget: min available: expected a non-negative integer or percentage but got 'half'")
+	})
+}
+
+func TestInitWithLockFile(t *testing.T) {
+	td := changeToTempDir(t)
+	files := map[string][]byte{
+		"/one.provisioners.yaml": []byte(`
+- uri: template://one
+  type: thing
+`),
+		"/patch-template": []byte(`[]`),
+	}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if raw, ok := files[r.URL.Path]; ok {
+			_, _ = w.Write(raw)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer svr.Close()
+	provisionersFile := svr.URL + "/one.provisioners.yaml"
+	patchFile := svr.URL + "/patch-template"
+	loadLock := func(t *testing.T) *project.LockFile {
+		lock, ok, err := project.LoadLockFile(filepath.Join(td, ".score-k8s"))
+		require.NoError(t, err)
+		require.True(t, ok)
+		return lock
+	}
+
+	t.Run("frozen without lock", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample", "--frozen", "--provisioners", provisionersFile})
+		assert.EqualError(t, err, fmt.Sprintf("failed to load provisioner 1: '%s' is not in the lock file, run init without --frozen to add it", provisionersFile))
+		_, err = os.Stat(filepath.Join(td, ".score-k8s", "lock.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("new", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample", "--provisioners", provisionersFile, "--patch-templates", patchFile})
+		require.NoError(t, err)
+		assert.Equal(t, &project.LockFile{
+			Provisioners: []project.LockedFile{{
+				Source: provisionersFile, Uri: provisionersFile,
+				Sha256: "0796ca8fd6daca2560edf025636be6892cd16a5bd8e2cc603056ee2d4b2667be",
+			}},
+			PatchTemplates: []project.LockedFile{{
+				Source: patchFile, Uri: patchFile,
+				Sha256: "4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945",
+			}},
+		}, loadLock(t))
+	})
+
+	t.Run("frozen unchanged", func(t *testing.T) {
+		before := loadLock(t)
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample", "--frozen", "--provisioners", provisionersFile, "--patch-templates", patchFile})
+		require.NoError(t, err)
+		assert.Equal(t, before, loadLock(t))
+	})
+
+	files["/one.provisioners.yaml"] = []byte(`
+- uri: template://one
+  type: other-thing
+`)
+
+	for _, args := range [][]string{{}, {"--frozen"}} {
+		t.Run(fmt.Sprintf("changed %v", args), func(t *testing.T) {
+			_, _, err := executeAndResetCommand(context.Background(), rootCmd, append([]string{"init", "--no-sample", "--provisioners", provisionersFile}, args...))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), fmt.Sprintf("failed to load provisioner 1: content fetched from '%s' does not match the lock file: file '%s' changed from sha256 ", provisionersFile, provisionersFile))
+			assert.Contains(t, err.Error(), ", run init with --upgrade to update the lock file")
+		})
+	}
+
+	t.Run("frozen and upgrade", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample", "--frozen", "--upgrade"})
+		assert.EqualError(t, err, "if any flags in the group [frozen upgrade] are set none of the others can be; [frozen upgrade] were all set")
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		before := loadLock(t)
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample", "--upgrade", "--provisioners", provisionersFile})
+		require.NoError(t, err)
+		after := loadLock(t)
+		assert.NotEqual(t, before.Provisioners[0].Sha256, after.Provisioners[0].Sha256)
+		assert.Equal(t, before.PatchTemplates, after.PatchTemplates)
+
+		provs, err := loader.LoadProvisionersFromDirectory(filepath.Join(td, ".score-k8s"), loader.DefaultSuffix)
+		require.NoError(t, err)
+		assert.Equal(t, "other-thing", provs[0].Type())
+	})
+
+	t.Run("local files are not locked", func(t *testing.T) {
+		before := loadLock(t)
+		localFile := filepath.Join(td, "local.provisioners.yaml")
+		require.NoError(t, os.WriteFile(localFile, []byte(`
+- uri: template://local
+  type: thing
+`), 0644))
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample", "--frozen", "--provisioners", localFile})
+		require.NoError(t, err)
+		assert.Equal(t, before, loadLock(t))
+	})
+}
+
+func TestInitWithLocalFilesOnly(t *testing.T) {
+	td := changeToTempDir(t)
+	localFile := filepath.Join(td, "local.provisioners.yaml")
+	require.NoError(t, os.WriteFile(localFile, []byte(`
+- uri: template://local
+  type: thing
+`), 0644))
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample", "--provisioners", localFile})
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(td, ".score-k8s", "lock.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCompareLockedFiles(t *testing.T) {
+	assert.Equal(t, []string{
+		"file 'b' changed from sha256 1 to 2",
+		"file 'c' was removed",
+		"file 'd' was added",
+	}, compareLockedFiles([]project.LockedFile{
+		{Uri: "a", Sha256: "1"}, {Uri: "b", Sha256: "1"}, {Uri: "c", Sha256: "1"},
+	}, []project.LockedFile{
+		{Uri: "a", Sha256: "1"}, {Uri: "b", Sha256: "2"}, {Uri: "d", Sha256: "1"},
+	}))
+}
+
+func TestResolveOciDigest(t *testing.T) {
+	u, err := url.Parse("oci://ghcr.io/example/provisioners@sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08#p.yaml")
+	require.NoError(t, err)
+	revision, err := resolveOciDigest(context.Background(), u)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", revision)
+}
+
+func TestPinOciSource(t *testing.T) {
+	u, err := url.Parse("oci://ghcr.io/example/provisioners:v1#p.yaml")
+	require.NoError(t, err)
+	pinned, err := pinOciSource(u, "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	require.NoError(t, err)
+	assert.Equal(t, "oci://ghcr.io/example/provisioners@sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08#p.yaml", pinned)
+}
+
+func TestFetchGitFiles(t *testing.T) {
+	td := t.TempDir()
+	git := func(args ...string) string {
+		c := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		c.Dir = td
+		output, err := c.CombinedOutput()
+		require.NoError(t, err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	require.NoError(t, os.MkdirAll(filepath.Join(td, "provisioners"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "provisioners", "a.provisioners.yaml"), []byte("[]"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "other.yaml"), []byte("{}"), 0644))
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial")
+	commit := git("rev-parse", "HEAD")
+
+	files, revision, err := fetchGitFiles(context.Background(), td, "provisioners", "git-https://example.com/repo.git/provisioners")
+	require.NoError(t, err)
+	assert.Equal(t, commit, revision)
+	assert.Equal(t, []uriget.FileContent{{URI: "provisioners/a.provisioners.yaml", Content: []byte("[]")}}, files)
+
+	files, revision, err = fetchGitFiles(context.Background(), td, "other.yaml", "git-https://example.com/repo.git/other.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, commit, revision)
+	assert.Equal(t, []uriget.FileContent{{URI: "git-https://example.com/repo.git/other.yaml", Content: []byte("{}")}}, files)
+}
+
+func TestInitWithSignedProvisioners(t *testing.T) {
+	td := changeToTempDir(t)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rawPub, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	keyFile := filepath.Join(td, "trusted.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rawPub}), 0600))
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	content := []byte(`
+- uri: cmd://signed
+  type: thing
+`)
+	files := map[string][]byte{
+		"/signed.yaml":       content,
+		"/signed.yaml.sig":   []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content))),
+		"/bad.yaml":          content,
+		"/bad.yaml.sig":      []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(otherPriv, content))),
+		"/unsigned.yaml":     content,
+		"/other-signed.yaml": append([]byte("# modified\n"), content...),
+	}
+	files["/other-signed.yaml.sig"] = files["/signed.yaml.sig"]
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if raw, ok := files[r.URL.Path]; ok {
+			_, _ = w.Write(raw)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer svr.Close()
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	countProvisioners := func(t *testing.T) int {
+		provs, err := loader.LoadProvisionersFromDirectory(filepath.Join(td, ".score-k8s"), loader.DefaultSuffix)
+		require.NoError(t, err)
+		return len(provs)
+	}
+	initial := countProvisioners(t)
+
+	t.Run("signed", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--trusted-key", keyFile, "--provisioners", svr.URL + "/signed.yaml"})
+		require.NoError(t, err)
+		assert.Equal(t, initial+1, countProvisioners(t))
+	})
+
+	for name, expected := range map[string]string{
+		"bad":          "signature is not valid for any of the 1 trusted keys",
+		"unsigned":     "no signature found",
+		"other-signed": "signature is not valid for any of the 1 trusted keys",
+	} {
+		t.Run(name, func(t *testing.T) {
+			uri := svr.URL + "/" + name + ".yaml"
+			_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--trusted-key", keyFile, "--provisioners", uri})
+			assert.EqualError(t, err, fmt.Sprintf("failed to load provisioner 1: failed to verify the signature of '%s': %s, use --allow-unsigned to install it anyway", uri, expected))
+			assert.Equal(t, initial+1, countProvisioners(t))
+		})
+	}
+
+	t.Run("allow unsigned", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--trusted-key", keyFile, "--allow-unsigned", "--provisioners", svr.URL + "/unsigned.yaml"})
+		require.NoError(t, err)
+		assert.Equal(t, initial+2, countProvisioners(t))
+	})
+
+	t.Run("no trusted keys", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--provisioners", svr.URL + "/bad.yaml"})
+		require.NoError(t, err)
+		assert.Equal(t, initial+3, countProvisioners(t))
+	})
+
+	t.Run("keys directory", func(t *testing.T) {
+		require.NoError(t, os.Mkdir(filepath.Join(td, ".score-k8s", "trusted-keys"), 0755))
+		require.NoError(t, os.Rename(keyFile, filepath.Join(td, ".score-k8s", "trusted-keys", "trusted.pem")))
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--provisioners", svr.URL + "/other-signed.yaml"})
+		assert.ErrorContains(t, err, "signature is not valid for any of the 1 trusted keys")
+		_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--upgrade", "--provisioners", svr.URL + "/signed.yaml"})
+		require.NoError(t, err)
+	})
+}
+
+func TestVerifyProvisionerFiles_directory(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys := []signature.TrustedKey{{Name: "key", Key: pub}}
+	one, two := []byte("- {uri: 'template://one', type: thing}"), []byte("- {uri: 'template://two', type: thing}")
+	files := []uriget.FileContent{
+		{URI: "dir/one.yaml", Content: one},
+		{URI: "dir/one.yaml.sig", Content: ed25519.Sign(priv, one)},
+		{URI: "dir/two.yaml", Content: two},
+	}
+
+	_, err = verifyProvisionerFiles(context.Background(), "git-https://example.com/repo.git/dir", files, keys, false)
+	assert.EqualError(t, err, "failed to verify the signature of 'dir/two.yaml': no signature found, use --allow-unsigned to install it anyway")
+
+	out, err := verifyProvisionerFiles(context.Background(), "git-https://example.com/repo.git/dir", files, keys, true)
+	require.NoError(t, err)
+	assert.Equal(t, []uriget.FileContent{files[0], files[2]}, out)
+
+	out, err = verifyProvisionerFiles(context.Background(), "./dir", files, keys, false)
+	require.NoError(t, err)
+	assert.Equal(t, []uriget.FileContent{files[0], files[2]}, out)
+}
@@ -0,0 +1,295 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/score-spec/score-go/uriget"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// lockMode controls how the files fetched from a source are checked against the lock file.
+type lockMode int
+
+const (
+	// lockModeDefault adds sources that are not in the lock file and fails when the content of a locked source changed.
+	lockModeDefault lockMode = iota
+	// lockModeFrozen fails when a source is not in the lock file or when its content changed.
+	lockModeFrozen
+	// lockModeUpgrade replaces the locked files of a source with the fetched files.
+	lockModeUpgrade
+)
+
+// isRemoteSource returns whether the source uri is fetched over the network. Only these sources are locked, local files
+// and stdin are not.
+func isRemoteSource(source string) bool {
+	u, err := url.Parse(source)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "git-ssh", "git-https", "oci":
+		return true
+	}
+	return false
+}
+
+// fetchLockedFiles fetches the files from the source uri and checks them against the files locked for the source. The
+// fetched files are returned along with the new locked files for the source. Sources that are not remote are fetched
+// without being locked.
+func fetchLockedFiles(ctx context.Context, source string, locked []project.LockedFile, mode lockMode) ([]uriget.FileContent, []project.LockedFile, error) {
+	if !isRemoteSource(source) {
+		files, err := uriget.GetFiles(ctx, source)
+		return files, nil, err
+	}
+	files, revision, err := fetchSourceRevision(ctx, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	fetched := make([]project.LockedFile, len(files))
+	for i, f := range files {
+		checksum := sha256.Sum256(f.Content)
+		fetched[i] = project.LockedFile{Source: source, Uri: f.URI, Revision: revision, Sha256: hex.EncodeToString(checksum[:])}
+	}
+
+	previous := slices.DeleteFunc(slices.Clone(locked), func(l project.LockedFile) bool {
+		return l.Source != source
+	})
+	if len(previous) == 0 {
+		if mode == lockModeFrozen {
+			return nil, nil, fmt.Errorf("'%s' is not in the lock file, run init without --%s to add it", source, initCmdFrozenFlag)
+		}
+		return files, fetched, nil
+	} else if mode == lockModeUpgrade {
+		return files, fetched, nil
+	}
+	if changes := compareLockedFiles(previous, fetched); len(changes) > 0 {
+		return nil, nil, fmt.Errorf("content fetched from '%s' does not match the lock file: %s, run init with --%s to update the lock file",
+			source, strings.Join(changes, ", "), initCmdUpgradeFlag)
+	}
+	return files, fetched, nil
+}
+
+// compareLockedFiles returns a description of each file that was added, removed, or changed.
+func compareLockedFiles(previous []project.LockedFile, fetched []project.LockedFile) []string {
+	var changes []string
+	for _, p := range previous {
+		index := slices.IndexFunc(fetched, func(f project.LockedFile) bool {
+			return f.Uri == p.Uri
+		})
+		if index < 0 {
+			changes = append(changes, fmt.Sprintf("file '%s' was removed", p.Uri))
+		} else if fetched[index].Sha256 != p.Sha256 {
+			changes = append(changes, fmt.Sprintf("file '%s' changed from sha256 %s to %s", p.Uri, p.Sha256, fetched[index].Sha256))
+		}
+	}
+	for _, f := range fetched {
+		if !slices.ContainsFunc(previous, func(p project.LockedFile) bool {
+			return p.Uri == f.Uri
+		}) {
+			changes = append(changes, fmt.Sprintf("file '%s' was added", f.Uri))
+		}
+	}
+	return changes
+}
+
+// replaceLockedFiles replaces the locked files of the source, keeping the position of the source in the lock file.
+func replaceLockedFiles(locked []project.LockedFile, source string, files []project.LockedFile) []project.LockedFile {
+	index := slices.IndexFunc(locked, func(l project.LockedFile) bool {
+		return l.Source == source
+	})
+	if index < 0 {
+		return append(locked, files...)
+	}
+	out := slices.DeleteFunc(slices.Clone(locked), func(l project.LockedFile) bool {
+		return l.Source == source
+	})
+	return slices.Insert(out, index, files...)
+}
+
+// fetchSourceRevision fetches the files from the source uri along with the git commit or OCI manifest digest that they
+// were fetched from, so that the revision always matches the content. Other sources do not have a revision.
+func fetchSourceRevision(ctx context.Context, source string) ([]uriget.FileContent, string, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "git-ssh", "git-https":
+		parts := strings.SplitN(u.Path, ".git/", 2)
+		if len(parts) == 1 || parts[0] == "" || strings.TrimSuffix(parts[1], "/") == "" {
+			return nil, "", fmt.Errorf("invalid git url, expected a path with ../<REPO>.git/<PATH>")
+		}
+		remoteUrl := &url.URL{Scheme: strings.TrimPrefix(strings.ToLower(u.Scheme), "git-"), User: u.User, Host: u.Host, Path: parts[0] + ".git"}
+		return fetchGitFiles(ctx, remoteUrl.String(), strings.TrimSuffix(parts[1], "/"), source)
+	case "oci":
+		// Resolve the digest first and then fetch that exact manifest, in case the tag is moved in between.
+		digest, err := resolveOciDigest(ctx, u)
+		if err != nil {
+			return nil, "", err
+		}
+		pinned, err := pinOciSource(u, digest)
+		if err != nil {
+			return nil, "", err
+		}
+		files, err := uriget.GetFiles(ctx, pinned)
+		if err != nil {
+			return nil, "", err
+		}
+		for i := range files {
+			files[i].URI = source
+		}
+		return files, digest, nil
+	}
+	files, err := uriget.GetFiles(ctx, source)
+	return files, "", err
+}
+
+// fetchGitFiles fetches the file or the files in the directory at the path of the remote repository with a sparse
+// checkout of its HEAD, in the same way as the uri retrieval, and returns them along with the commit that was checked
+// out.
+func fetchGitFiles(ctx context.Context, remoteUrl string, subPath string, source string) ([]uriget.FileContent, string, error) {
+	td, err := os.MkdirTemp("", "score-k8s-git")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to make temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(td) }()
+	git := func(args ...string) (string, error) {
+		c := exec.CommandContext(ctx, "git", args...)
+		c.Dir = td
+		output, err := c.Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", remoteUrl},
+		{"sparse-checkout", "set", "--no-cone", "--sparse-index", subPath},
+		{"pull", "--quiet", "origin", "HEAD", "--depth=1"},
+	} {
+		if _, err := git(args...); err != nil {
+			return nil, "", err
+		}
+	}
+	revision, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+
+	fullPath := filepath.Join(td, subPath)
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, "", err
+	} else if !info.IsDir() {
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read file: %w", err)
+		}
+		return []uriget.FileContent{{URI: source, Content: content}}, revision, nil
+	}
+	entries, err := os.ReadDir(fullPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read directory: %w", err)
+	}
+	var out []uriget.FileContent
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(fullPath, entry.Name()))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		out = append(out, uriget.FileContent{URI: subPath + "/" + entry.Name(), Content: content})
+	}
+	if len(out) == 0 {
+		return nil, "", fmt.Errorf("directory %s contains no files", subPath)
+	}
+	return out, revision, nil
+}
+
+// pinOciSource returns the OCI source uri with its tag replaced by the manifest digest.
+func pinOciSource(u *url.URL, digest string) (string, error) {
+	ref, err := registry.ParseReference(u.Host + u.Path)
+	if err != nil {
+		return "", fmt.Errorf("invalid artifact URL: %w", err)
+	}
+	pinned := *u
+	pinned.Path = "/" + ref.Repository + "@" + digest
+	pinned.RawPath = ""
+	return pinned.String(), nil
+}
+
+// resolveOciDigest returns the digest of the manifest that the OCI reference refers to.
+func resolveOciDigest(ctx context.Context, u *url.URL) (string, error) {
+	ref, err := registry.ParseReference(u.Host + u.Path)
+	if err != nil {
+		return "", fmt.Errorf("invalid artifact URL: %w", err)
+	}
+	if ref.Reference == "" {
+		ref.Reference = "latest"
+	}
+	if _, err := ref.Digest(); err == nil {
+		return ref.Reference, nil
+	}
+	repo, err := newOciRepository(ref)
+	if err != nil {
+		return "", err
+	}
+	desc, err := repo.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve manifest: %w", err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// newOciRepository connects to the remote repository in the same way as the uri retrieval of init, using any docker
+// credentials.
+func newOciRepository(ref registry.Reference) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(ref.String())
+	if err != nil {
+		return nil, fmt.Errorf("connection to remote repository failed: %w", err)
+	}
+	repo.PlainHTTP = strings.HasPrefix(ref.Registry, "localhost") || strings.HasPrefix(ref.Registry, "127.0.0.1")
+	client := &auth.Client{Client: retry.DefaultClient, Cache: auth.NewCache()}
+	if credStore, err := credentials.NewStoreFromDocker(credentials.StoreOptions{}); err == nil {
+		client.Credential = credentials.Credential(credStore)
+	}
+	repo.Client = client
+	return repo, nil
+}
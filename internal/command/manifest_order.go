@@ -0,0 +1,73 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"slices"
+)
+
+// manifestKindInstallOrder is the order in which manifest kinds are written to the output. This follows the install
+// order used by Helm so that dependencies such as namespaces, secrets, and config maps exist before the workloads that
+// consume them. Kinds not in this list are written last.
+var manifestKindInstallOrder = []string{
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+}
+
+// manifestKindRank returns the install position of the manifest kind.
+func manifestKindRank(manifest map[string]interface{}) int {
+	kind, _ := manifest["kind"].(string)
+	if i := slices.Index(manifestKindInstallOrder, kind); i >= 0 {
+		return i
+	}
+	return len(manifestKindInstallOrder)
+}
+
+// sortManifestsByInstallOrder sorts the manifests by kind install order. The sort is stable so that manifests of the
+// same kind retain their existing order: resource manifests in dependency order followed by workload manifests in
+// workload name order.
+func sortManifestsByInstallOrder(manifests []map[string]interface{}) {
+	slices.SortStableFunc(manifests, func(a, b map[string]interface{}) int {
+		return manifestKindRank(a) - manifestKindRank(b)
+	})
+}
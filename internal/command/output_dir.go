@@ -0,0 +1,141 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const kustomizationFileName = "kustomization.yaml"
+
+type kustomization struct {
+	ApiVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Resources  []string `yaml:"resources"`
+}
+
+var unsafePathCharacters = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// buildManifestFilePath returns the relative path of the file for the manifest in the output directory. Manifests that
+// were not produced by a workload or resource, such as the namespace, are written to the root of the directory.
+func buildManifestFilePath(manifest map[string]interface{}, source string) string {
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	fileName := unsafePathCharacters.ReplaceAllString(strings.ToLower(kind)+"-"+name, "_") + ".yaml"
+	if source == "" {
+		return fileName
+	}
+	return filepath.Join(unsafePathCharacters.ReplaceAllString(source, "_"), fileName)
+}
+
+// writeManifestsToDirectory writes each manifest to its own file in the directory along with a kustomization.yaml
+// that lists them all. Any files listed by a previous kustomization.yaml that are no longer generated are removed so
+// that the directory can be committed and applied as a whole.
+func writeManifestsToDirectory(dir string, manifests []map[string]interface{}, manifestSources map[string]string, outputFormat string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	previousFiles, err := readKustomizationResources(dir)
+	if err != nil {
+		return err
+	}
+
+	k := kustomization{ApiVersion: "kustomize.config.k8s.io/v1beta1", Kind: "Kustomization", Resources: make([]string, 0, len(manifests))}
+	for _, manifest := range manifests {
+		relPath := buildManifestFilePath(manifest, manifestSources[buildManifestSourceKey(manifest)])
+		if slices.Contains(k.Resources, filepath.ToSlash(relPath)) {
+			return fmt.Errorf("multiple manifests would be written to '%s'", relPath)
+		}
+		content, err := encodeManifests([]map[string]interface{}{manifest}, outputFormat)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomically(filepath.Join(dir, relPath), content.Bytes()); err != nil {
+			return err
+		}
+		k.Resources = append(k.Resources, filepath.ToSlash(relPath))
+	}
+
+	for _, previous := range previousFiles {
+		if slices.Contains(k.Resources, previous) || !filepath.IsLocal(previous) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, previous)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove stale manifest: %w", err)
+		}
+		slog.Info(fmt.Sprintf("Removed stale manifest '%s'", previous))
+		// remove the parent directory too if it is now empty
+		if parent := filepath.Dir(previous); parent != "." {
+			if entries, err := os.ReadDir(filepath.Join(dir, parent)); err == nil && len(entries) == 0 {
+				_ = os.Remove(filepath.Join(dir, parent))
+			}
+		}
+	}
+
+	if err := writeYamlFile(filepath.Join(dir, kustomizationFileName), k); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Wrote %d manifests to '%s'", len(manifests), dir))
+	return nil
+}
+
+// readKustomizationResources returns the resources listed in an existing kustomization.yaml in the directory.
+func readKustomizationResources(dir string) ([]string, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, kustomizationFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read existing kustomization: %w", err)
+	}
+	var k kustomization
+	if err := yaml.Unmarshal(raw, &k); err != nil {
+		return nil, fmt.Errorf("failed to decode existing kustomization: %w", err)
+	}
+	return k.Resources, nil
+}
+
+func writeYamlFile(path string, content interface{}) error {
+	out := new(bytes.Buffer)
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(content); err != nil {
+		return fmt.Errorf("failed to encode '%s': %w", filepath.Base(path), err)
+	}
+	return writeFileAtomically(path, out.Bytes())
+}
+
+func writeFileAtomically(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	} else if err := os.WriteFile(path+".tmp", content, 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	} else if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to complete writing output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/convert"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// helmGeneratedTemplatesFileName is the file in the chart directory that lists the templates written by the last run,
+// so that templates which are no longer generated can be removed without touching hand-written ones.
+const helmGeneratedTemplatesFileName = ".score-k8s-templates.yaml"
+
+type helmGeneratedTemplates struct {
+	Templates []string `yaml:"templates"`
+}
+
+type helmChartMetadata struct {
+	ApiVersion  string `yaml:"apiVersion"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Type        string `yaml:"type"`
+	Version     string `yaml:"version"`
+}
+
+// helmValues collects the values lifted out of the manifests along with the placeholders that stand in for them in
+// the manifests until they are rendered as templates.
+type helmValues struct {
+	Workloads    map[string]interface{}
+	placeholders map[string]string
+}
+
+// placeholder returns a unique placeholder string which is later replaced by a template expression that renders the
+// value at the given path of values.yaml.
+func (hv *helmValues) placeholder(path ...string) string {
+	token := fmt.Sprintf("__score-k8s-helm-value-%d__", len(hv.placeholders))
+	quoted := make([]string, len(path))
+	for i, p := range path {
+		quoted[i] = strconv.Quote(p)
+	}
+	hv.placeholders[token] = fmt.Sprintf("{{ index .Values %s | toJson }}", strings.Join(quoted, " "))
+	return token
+}
+
+// liftWorkloadValues moves the replicas and the container images and resources of a workload manifest into the values
+// and replaces them with placeholders. The replicas of an autoscaled workload are left to its HorizontalPodAutoscaler.
+func (hv *helmValues) liftWorkloadValues(workloadName string, manifest map[string]interface{}, autoscaled bool) {
+	kind, _ := manifest["kind"].(string)
+	spec, _ := manifest["spec"].(map[string]interface{})
+	if spec == nil {
+		return
+	}
+	workloadValues := make(map[string]interface{})
+	podOwnerSpec := spec
+	switch kind {
+	case convert.WorkloadKindDeployment, convert.WorkloadKindStatefulSet:
+		if autoscaled {
+			break
+		}
+		replicas, ok := spec["replicas"]
+		if !ok {
+			replicas = 1
+		}
+		workloadValues["replicas"] = replicas
+		spec["replicas"] = hv.placeholder("workloads", workloadName, "replicas")
+	case convert.WorkloadKindCronJob:
+		jobTemplate, _ := spec["jobTemplate"].(map[string]interface{})
+		podOwnerSpec, _ = jobTemplate["spec"].(map[string]interface{})
+	case convert.WorkloadKindDaemonSet, convert.WorkloadKindJob:
+	default:
+		return
+	}
+
+	podTemplate, _ := podOwnerSpec["template"].(map[string]interface{})
+	podSpec, _ := podTemplate["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+	containerValues := make(map[string]interface{}, len(containers))
+	for _, rawContainer := range containers {
+		container, _ := rawContainer.(map[string]interface{})
+		name, _ := container["name"].(string)
+		if name == "" {
+			continue
+		}
+		resources, ok := container["resources"]
+		if !ok {
+			resources = map[string]interface{}{}
+		}
+		containerValues[name] = map[string]interface{}{
+			"image":     container["image"],
+			"resources": resources,
+		}
+		container["image"] = hv.placeholder("workloads", workloadName, "containers", name, "image")
+		container["resources"] = hv.placeholder("workloads", workloadName, "containers", name, "resources")
+	}
+	workloadValues["containers"] = containerValues
+	hv.Workloads[workloadName] = workloadValues
+}
+
+// render encodes the manifest as a Helm template. Any existing template delimiters are escaped so that Helm outputs
+// them as-is, then the placeholders are replaced by their value expressions.
+func (hv *helmValues) render(manifest map[string]interface{}) ([]byte, error) {
+	content, err := encodeManifests([]map[string]interface{}{manifest}, outputFormatYaml)
+	if err != nil {
+		return nil, err
+	}
+	rendered := strings.ReplaceAll(content.String(), "{{", `{{ "{{" }}`)
+	for token, expression := range hv.placeholders {
+		rendered = strings.ReplaceAll(rendered, token, expression)
+	}
+	return []byte(rendered), nil
+}
+
+// writeHelmChart writes the manifests as a Helm chart in the directory. The chart name is the name of the directory.
+// Templates written by a previous run that are no longer generated are removed, other files in the templates directory
+// are left as-is.
+func writeHelmChart(dir string, chartVersion string, manifests []map[string]interface{}, manifestSources map[string]string, state *project.State) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	templatesDir := filepath.Join(dir, "templates")
+	previousTemplates, err := readHelmGeneratedTemplates(dir)
+	if err != nil {
+		return err
+	}
+
+	hv := &helmValues{Workloads: make(map[string]interface{}), placeholders: make(map[string]string)}
+	writtenTemplates := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		source := manifestSources[buildManifestSourceKey(manifest)]
+		metadata, _ := manifest["metadata"].(map[string]interface{})
+		if workload, ok := state.Workloads[source]; ok && metadata["name"] == source {
+			_, autoscaled := internal.FindAnnotation(workload.Spec.Metadata, internal.WorkloadMaxReplicasAnnotation)
+			hv.liftWorkloadValues(source, manifest, autoscaled)
+		}
+		relPath := buildManifestFilePath(manifest, source)
+		if slices.Contains(writtenTemplates, filepath.ToSlash(relPath)) {
+			return fmt.Errorf("multiple manifests would be written to '%s'", relPath)
+		}
+		content, err := hv.render(manifest)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomically(filepath.Join(templatesDir, relPath), content); err != nil {
+			return err
+		}
+		writtenTemplates = append(writtenTemplates, filepath.ToSlash(relPath))
+	}
+
+	for _, previous := range previousTemplates {
+		if slices.Contains(writtenTemplates, previous) || !filepath.IsLocal(previous) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(templatesDir, previous)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove stale template: %w", err)
+		}
+		slog.Info(fmt.Sprintf("Removed stale template '%s'", previous))
+		if parent := filepath.Dir(previous); parent != "." {
+			if entries, err := os.ReadDir(filepath.Join(templatesDir, parent)); err == nil && len(entries) == 0 {
+				_ = os.Remove(filepath.Join(templatesDir, parent))
+			}
+		}
+	}
+
+	if err := writeYamlFile(filepath.Join(dir, helmGeneratedTemplatesFileName), helmGeneratedTemplates{Templates: writtenTemplates}); err != nil {
+		return err
+	}
+	if err := writeYamlFile(filepath.Join(dir, "values.yaml"), map[string]interface{}{"workloads": hv.Workloads}); err != nil {
+		return err
+	}
+	if err := writeYamlFile(filepath.Join(dir, "Chart.yaml"), helmChartMetadata{
+		ApiVersion:  "v2",
+		Name:        filepath.Base(absDir),
+		Description: "A Helm chart generated by score-k8s",
+		Type:        "application",
+		Version:     chartVersion,
+	}); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Wrote Helm chart with %d templates to '%s'", len(writtenTemplates), dir))
+	return nil
+}
+
+// readHelmGeneratedTemplates returns the templates written by a previous run to the chart in the directory.
+func readHelmGeneratedTemplates(dir string) ([]string, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, helmGeneratedTemplatesFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read existing generated templates list: %w", err)
+	}
+	var g helmGeneratedTemplates
+	if err := yaml.Unmarshal(raw, &g); err != nil {
+		return nil, fmt.Errorf("failed to decode existing generated templates list: %w", err)
+	}
+	return g.Templates, nil
+}
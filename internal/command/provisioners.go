@@ -15,18 +15,33 @@
 package command
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
-	"sort"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/score-spec/score-go/formatter"
+	"github.com/score-spec/score-go/framework"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
 	"github.com/score-spec/score-k8s/internal/project"
 	"github.com/score-spec/score-k8s/internal/provisioners"
+	"github.com/score-spec/score-k8s/internal/provisioners/cmdprov"
+	"github.com/score-spec/score-k8s/internal/provisioners/httpprov"
 	"github.com/score-spec/score-k8s/internal/provisioners/loader"
-	"github.com/score-spec/score-go/formatter"
+	"github.com/score-spec/score-k8s/internal/provisioners/templateprov"
 )
 
 var (
@@ -44,6 +59,70 @@ after 'init' or 'generate' has been run. The list of provisioners will be empty
 		SilenceErrors: true,
 		RunE:          listProvisioners,
 	}
+	provisionersDescribe = &cobra.Command{
+		Use:   "describe URI|TYPE[.CLASS]",
+		Short: "Describe a provisioner",
+		Long: `The describe command will show the details of the provisioner with the given uri, or of each provisioner that
+matches a resource of the given type and class (default 'default') that does not set an id. This includes the file it
+was loaded from, the type, class, id, priority, and match conditions that it matches, the params and outputs along with
+their JSON schemas, the templates of template provisioners, and the resources in the state that it has provisioned.
+
+When describing by type and class, the provisioners are listed in order of precedence. A resource is provisioned by the
+first of them whose match conditions it meets.
+`,
+		Example: `
+  # Describe a provisioner by uri
+  score-k8s provisioners describe template://default-provisioners/postgres
+
+  # Describe the provisioners that match resources of a type and class
+  score-k8s provisioners describe postgres.default`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		RunE:          describeProvisioner,
+	}
+	provisionersTest = &cobra.Command{
+		Use:   "test FILE",
+		Short: "Test the provisioners in a file against fixture inputs",
+		Long: `The test command loads the provisioners file and provisions a resource for each fixture file in the fixtures
+directory, which defaults to the testdata directory next to the provisioners file. The resulting outputs, state, shared
+state modifications, and manifests, or the error, are compared with a golden file next to each fixture and a diff is
+shown for each fixture that does not match. Use --update to write the golden files instead.
+
+Each fixture file is named NAME.fixture.yaml and contains the input that is sent to the provisioner, in the same form as
+the JSON input sent to cmd provisioners, along with an optional provisioner uri. When no uri is set, the provisioner is
+the one in the file that generate would match to the resource in the input. The golden file is named NAME.golden.yaml.
+
+  provisioner: template://custom-provisioners/postgres
+  input:
+    resource_uid: postgres.default#my-workload.db
+    resource_guid: 00000000-0000-0000-0000-000000000000
+    resource_params:
+      version: "16"
+    source_workload: my-workload
+    namespace: default
+    resource_state: {}
+    shared_state: {}
+
+The command exits with an error when any fixture fails. This does not require a project state directory.
+`,
+		Example: `
+  # Test the provisioners against the fixtures in ./testdata
+  score-k8s provisioners test ./custom.provisioners.yaml
+
+  # Update the golden files after changing the provisioners
+  score-k8s provisioners test ./custom.provisioners.yaml --update`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		RunE:          testProvisioners,
+	}
+)
+
+const (
+	provisionersTestCmdFixturesFlag = "fixtures"
+	provisionersTestCmdUpdateFlag   = "update"
+
+	provisionerFixtureSuffix = ".fixture.yaml"
+	provisionerGoldenSuffix  = ".golden.yaml"
 )
 
 func listProvisioners(cmd *cobra.Command, args []string) error {
@@ -78,46 +157,408 @@ func displayProvisioners(loadedProvisioners []provisioners.Provisioner, outputFo
 	switch outputFormat {
 	case "json":
 		type jsonData struct {
-			Type        string
-			Class       string
-			Params      []string
-			Outputs     []string
-			Description string
+			Type          string
+			Class         string
+			Priority      int                           `json:",omitempty"`
+			Match         *provisioners.MatchConditions `json:",omitempty"`
+			Params        []string
+			Outputs       []string
+			Description   string
+			ParamsSchema  map[string]interface{} `json:",omitempty"`
+			OutputsSchema map[string]interface{} `json:",omitempty"`
 		}
 		var outputs []jsonData
 		for _, provisioner := range sortedProvisioners {
+			paramsSchema, outputsSchema := provisionerSchemas(provisioner)
+			conditions, priority := provisionerConditions(provisioner)
 			outputs = append(outputs, jsonData{
-				Type:        provisioner.Type(),
-				Class:       provisioner.Class(),
-				Params:      provisioner.Params(),
-				Outputs:     provisioner.Outputs(),
-				Description: provisioner.Description(),
+				Type:          provisioner.Type(),
+				Class:         provisioner.Class(),
+				Priority:      priority,
+				Match:         conditions,
+				Params:        provisioner.Params(),
+				Outputs:       provisioner.Outputs(),
+				Description:   provisioner.Description(),
+				ParamsSchema:  paramsSchema,
+				OutputsSchema: outputsSchema,
 			})
 		}
 		outputFormatter = &formatter.JSONOutputFormatter[[]jsonData]{Data: outputs}
 	default:
+		// The conditions column is only shown when any of the provisioners has match conditions or a priority.
+		withConditions := slices.ContainsFunc(sortedProvisioners, func(p provisioners.Provisioner) bool {
+			return describeProvisionerConditions(p) != ""
+		})
 		rows := [][]string{}
 		for _, provisioner := range sortedProvisioners {
-			rows = append(rows, []string{provisioner.Type(), provisioner.Class(), strings.Join(provisioner.Params(), ", "), strings.Join(provisioner.Outputs(), ", "), provisioner.Description()})
+			row := []string{provisioner.Type(), provisioner.Class()}
+			if withConditions {
+				row = append(row, describeProvisionerConditions(provisioner))
+			}
+			rows = append(rows, append(row, strings.Join(provisioner.Params(), ", "), strings.Join(provisioner.Outputs(), ", "), provisioner.Description()))
 		}
 		headers := []string{"Type", "Class", "Params", "Outputs", "Description"}
+		if withConditions {
+			headers = slices.Insert(headers, 2, "Conditions")
+		}
 		outputFormatter = &formatter.TableOutputFormatter{
 			Headers: headers,
 			Rows:    rows,
 		}
 	}
-		return outputFormatter.Display()
+	return outputFormatter.Display()
+}
+
+// provisionerConditions returns the match conditions and priority of the provisioner if it has any.
+func provisionerConditions(provisioner provisioners.Provisioner) (*provisioners.MatchConditions, int) {
+	if cp, ok := provisioner.(provisioners.ConditionalProvisioner); ok {
+		return cp.MatchConditions(), cp.Priority()
+	}
+	return nil, 0
+}
+
+// describeProvisionerConditions describes the priority and match conditions of the provisioner on a single line.
+func describeProvisionerConditions(provisioner provisioners.Provisioner) string {
+	conditions, priority := provisionerConditions(provisioner)
+	out := conditions.String()
+	if priority != 0 {
+		out = strings.TrimSuffix(fmt.Sprintf("priority=%d, %s", priority, out), ", ")
+	}
+	return out
+}
+
+// provisionerSchemas returns the params and outputs schemas of the provisioner if it has any.
+func provisionerSchemas(provisioner provisioners.Provisioner) (map[string]interface{}, map[string]interface{}) {
+	if sp, ok := provisioner.(provisioners.SchemaProvisioner); ok {
+		return sp.ParamsSchema(), sp.OutputsSchema()
+	}
+	return nil, nil
+}
+
+func describeProvisioner(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	sd, ok, err := project.LoadStateDirectory(".")
+	if err != nil {
+		return fmt.Errorf("failed to load existing state directory: %w", err)
+	} else if !ok {
+		return fmt.Errorf("no state directory found, run 'score-k8s init' first")
+	}
+
+	loadedProvisioners, sources, err := loader.LoadProvisionersFromDirectoryWithSources(sd.Path, loader.DefaultSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to load provisioners in %s: %w", sd.Path, err)
+	}
+	indices, err := findProvisionersToDescribe(loadedProvisioners, args[0])
+	if err != nil {
+		return err
+	}
+	for i, index := range indices {
+		if i > 0 {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout())
+		}
+		if err := writeProvisionerDescription(cmd.OutOrStdout(), loadedProvisioners[index], filepath.Join(sd.Path, sources[index]), &sd.State); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findProvisionersToDescribe returns the index of the provisioner with the uri, or otherwise the indices of the
+// provisioners that match the type and class of a TYPE[.CLASS] resource without an id, in order of precedence. The
+// match conditions are not evaluated since they depend on the resource metadata, params, and workload.
+func findProvisionersToDescribe(loadedProvisioners []provisioners.Provisioner, query string) ([]int, error) {
+	if strings.Contains(query, "://") {
+		index := slices.IndexFunc(loadedProvisioners, func(p provisioners.Provisioner) bool {
+			return p.Uri() == query
+		})
+		if index < 0 {
+			return nil, fmt.Errorf("no provisioner found with uri '%s'", query)
+		}
+		return []int{index}, nil
+	}
+	resType, resClass, hasClass := strings.Cut(query, ".")
+	var classRef *string
+	if hasClass {
+		classRef = &resClass
+	}
+	resUid := framework.NewResourceUid("workload", "resource", resType, classRef, nil)
+	var indices []int
+	for i, p := range loadedProvisioners {
+		if p.Match(resUid) {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no provisioner matches resources of type '%s' and class '%s'", resUid.Type(), resUid.Class())
+	}
+	slices.SortStableFunc(indices, func(a, b int) int {
+		return provisioners.ComparePrecedence(loadedProvisioners[a], loadedProvisioners[b])
+	})
+	return indices, nil
+}
+
+// provisionerImplementationDetails returns the id that the provisioner matches along with the named fields and
+// templates that are specific to the kind of provisioner.
+func provisionerImplementationDetails(provisioner provisioners.Provisioner) (*string, [][2]string, [][2]string) {
+	switch p := provisioner.(type) {
+	case *templateprov.Provisioner:
+		return p.ResId, nil, [][2]string{
+			{"Init template", p.InitTemplate},
+			{"State template", p.StateTemplate},
+			{"Shared template", p.SharedStateTemplate},
+			{"Outputs template", p.OutputsTemplate},
+			{"Manifests template", p.ManifestsTemplate},
+			{"RBAC rules template", p.RbacRulesTemplate},
+			{"Deprovision template", p.DeprovisionTemplate},
+		}
+	case *cmdprov.Provisioner:
+		return p.ResId, [][2]string{{"Args", strings.Join(p.Args, " ")}}, nil
+	case *httpprov.Provisioner:
+		timeout := p.Timeout
+		if timeout == "" {
+			timeout = httpprov.DefaultTimeout.String()
+		}
+		return p.ResId, [][2]string{
+			{"Headers", strings.Join(slices.Sorted(maps.Keys(p.Headers)), ", ")},
+			{"Timeout", timeout},
+			{"Retries", strconv.Itoa(p.Retries)},
+		}, nil
+	}
+	return nil, nil, nil
+}
+
+// writeProvisionerDescription writes the details of the provisioner in a human-readable form along with the resources
+// in the state that it has provisioned.
+func writeProvisionerDescription(w io.Writer, provisioner provisioners.Provisioner, source string, state *project.State) error {
+	orNone := func(value string) string {
+		if value == "" {
+			return "(none)"
+		}
+		return value
+	}
+	writeBlock := func(title string, content string) {
+		_, _ = fmt.Fprintf(w, "%s:\n", title)
+		for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+			_, _ = fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+
+	resId, fields, templates := provisionerImplementationDetails(provisioner)
+	id := "(any)"
+	if resId != nil {
+		id = *resId
+	}
+	_, _ = fmt.Fprintf(w, "URI:         %s\n", provisioner.Uri())
+	_, _ = fmt.Fprintf(w, "Source:      %s\n", source)
+	_, _ = fmt.Fprintf(w, "Type:        %s\n", provisioner.Type())
+	_, _ = fmt.Fprintf(w, "Class:       %s\n", provisioner.Class())
+	_, _ = fmt.Fprintf(w, "Id:          %s\n", id)
+	_, _ = fmt.Fprintf(w, "Conditions:  %s\n", orNone(describeProvisionerConditions(provisioner)))
+	_, _ = fmt.Fprintf(w, "Description: %s\n", orNone(provisioner.Description()))
+	_, _ = fmt.Fprintf(w, "Params:      %s\n", orNone(strings.Join(provisioner.Params(), ", ")))
+	_, _ = fmt.Fprintf(w, "Outputs:     %s\n", orNone(strings.Join(provisioner.Outputs(), ", ")))
+	for _, field := range fields {
+		_, _ = fmt.Fprintf(w, "%-13s%s\n", field[0]+":", orNone(field[1]))
+	}
+
+	paramsSchema, outputsSchema := provisionerSchemas(provisioner)
+	for _, section := range []struct {
+		title  string
+		schema map[string]interface{}
+	}{{"Params schema", paramsSchema}, {"Outputs schema", outputsSchema}} {
+		if section.schema == nil {
+			continue
+		}
+		raw := new(bytes.Buffer)
+		enc := yaml.NewEncoder(raw)
+		enc.SetIndent(2)
+		if err := enc.Encode(section.schema); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", strings.ToLower(section.title), err)
+		}
+		writeBlock(section.title, raw.String())
+	}
+	for _, template := range templates {
+		if strings.TrimSpace(template[1]) != "" {
+			writeBlock(template[0], template[1])
+		}
+	}
+
+	var resources []string
+	for _, resUid := range slices.Sorted(maps.Keys(state.Resources)) {
+		if res := state.Resources[resUid]; res.ProvisionerUri == provisioner.Uri() {
+			resources = append(resources, fmt.Sprintf("%s (workload '%s')", resUid, res.SourceWorkload))
+		}
+	}
+	writeBlock("Provisioned resources", orNone(strings.Join(resources, "\n")))
+	return nil
+}
+
+// provisionerFixture is the decoded fixture file for the provisioners test command.
+type provisionerFixture struct {
+	Provisioner string                 `yaml:"provisioner,omitempty"`
+	Input       map[string]interface{} `yaml:"input"`
+}
+
+// provisionerTestResult is the content of the golden file for a fixture.
+type provisionerTestResult struct {
+	Provisioner string                   `yaml:"provisioner,omitempty"`
+	Error       string                   `yaml:"error,omitempty"`
+	Outputs     map[string]interface{}   `yaml:"outputs,omitempty"`
+	State       map[string]interface{}   `yaml:"state,omitempty"`
+	Shared      map[string]interface{}   `yaml:"shared,omitempty"`
+	Manifests   []map[string]interface{} `yaml:"manifests,omitempty"`
+}
+
+func testProvisioners(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	raw, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read provisioners file: %w", err)
+	}
+	loadedProvisioners, err := loader.LoadProvisioners(raw)
+	if err != nil {
+		return fmt.Errorf("failed to load provisioners file '%s': %w", args[0], err)
+	}
+
+	fixturesDir, _ := cmd.Flags().GetString(provisionersTestCmdFixturesFlag)
+	if fixturesDir == "" {
+		fixturesDir = filepath.Join(filepath.Dir(args[0]), "testdata")
+	}
+	fixtureFiles, err := filepath.Glob(filepath.Join(fixturesDir, "*"+provisionerFixtureSuffix))
+	if err != nil {
+		return fmt.Errorf("failed to list fixtures: %w", err)
+	} else if len(fixtureFiles) == 0 {
+		return fmt.Errorf("no fixture files matching '*%s' found in '%s'", provisionerFixtureSuffix, fixturesDir)
+	}
+	update, _ := cmd.Flags().GetBool(provisionersTestCmdUpdateFlag)
+
+	var failures int
+	for _, fixtureFile := range fixtureFiles {
+		name := strings.TrimSuffix(filepath.Base(fixtureFile), provisionerFixtureSuffix)
+		goldenFile := strings.TrimSuffix(fixtureFile, provisionerFixtureSuffix) + provisionerGoldenSuffix
+		actual, err := runProvisionerFixture(cmd.Context(), loadedProvisioners, fixtureFile)
+		if err != nil {
+			return fmt.Errorf("fixture '%s': %w", name, err)
+		}
+
+		if update {
+			if err := os.WriteFile(goldenFile, []byte(actual), 0644); err != nil {
+				return fmt.Errorf("fixture '%s': failed to write golden file: %w", name, err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "UPDATED %s\n", name)
+			continue
+		}
+
+		expected, err := os.ReadFile(goldenFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("fixture '%s': failed to read golden file: %w", name, err)
+		}
+		if string(expected) == actual {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "PASS %s\n", name)
+			continue
+		}
+		failures++
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "FAIL %s\n", name)
+		fromFile := goldenFile
+		if err != nil {
+			fromFile = "/dev/null"
+		}
+		if err := difflib.WriteUnifiedDiff(cmd.OutOrStdout(), difflib.UnifiedDiff{
+			A:        splitDiffLines(string(expected)),
+			B:        splitDiffLines(actual),
+			FromFile: fromFile,
+			ToFile:   "actual",
+			Context:  3,
+		}); err != nil {
+			return fmt.Errorf("fixture '%s': failed to write diff: %w", name, err)
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d provisioner fixtures failed, run with --%s to update the golden files", failures, len(fixtureFiles), provisionersTestCmdUpdateFlag)
+	}
+	return nil
+}
+
+// runProvisionerFixture provisions the resource in the fixture file and returns the encoded result for comparison with
+// the golden file. Provisioning errors are part of the result so that failure cases can be tested too.
+func runProvisionerFixture(ctx context.Context, loadedProvisioners []provisioners.Provisioner, fixtureFile string) (string, error) {
+	raw, err := os.ReadFile(fixtureFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read fixture file: %w", err)
+	}
+	var fixture provisionerFixture
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fixture); err != nil {
+		return "", fmt.Errorf("failed to decode fixture file: %w", err)
+	}
+
+	// the input is decoded through json so that it has the same form as the input sent to cmd provisioners
+	rawInput, err := json.Marshal(fixture.Input)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode fixture input: %w", err)
+	}
+	input := new(provisioners.Input)
+	jsonDec := json.NewDecoder(bytes.NewReader(rawInput))
+	jsonDec.DisallowUnknownFields()
+	if err := jsonDec.Decode(input); err != nil {
+		return "", fmt.Errorf("failed to decode fixture input: %w", err)
+	} else if input.ResourceUid == "" {
+		return "", fmt.Errorf("input.resource_uid is not set")
+	}
+	resUid := framework.ResourceUid(input.ResourceUid)
+	if input.ResourceType == "" {
+		input.ResourceType, input.ResourceClass, input.ResourceId = resUid.Type(), resUid.Class(), resUid.Id()
+	}
+
+	var provisioner provisioners.Provisioner
+	if fixture.Provisioner != "" {
+		index := slices.IndexFunc(loadedProvisioners, func(p provisioners.Provisioner) bool {
+			return p.Uri() == fixture.Provisioner
+		})
+		if index < 0 {
+			return "", fmt.Errorf("no provisioner found with uri '%s'", fixture.Provisioner)
+		}
+		provisioner = loadedProvisioners[index]
+	} else {
+		var ok bool
+		if provisioner, ok = provisioners.MatchProvisioner(loadedProvisioners, input); !ok {
+			return "", fmt.Errorf("no provisioner matches resource '%s'", resUid)
+		}
+	}
+
+	result := provisionerTestResult{Provisioner: provisioner.Uri()}
+	if output, err := provisioners.ProvisionResource(ctx, provisioner, input); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Outputs = output.ResourceOutputs
+		result.State = output.ResourceState
+		result.Shared = output.SharedState
+		result.Manifests = output.Manifests
+	}
+	out := new(bytes.Buffer)
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(result); err != nil {
+		return "", fmt.Errorf("failed to encode result: %w", err)
+	}
+	return out.String(), nil
 }
 
-func sortProvisionersByType(provisioners []provisioners.Provisioner) []provisioners.Provisioner {
-	sort.Slice(provisioners, func(i, j int) bool {
-		return provisioners[i].Type() < provisioners[j].Type()
+// sortProvisionersByType sorts the provisioners by type and then in the order of precedence that they are matched in.
+func sortProvisionersByType(loadedProvisioners []provisioners.Provisioner) []provisioners.Provisioner {
+	slices.SortStableFunc(loadedProvisioners, func(a, b provisioners.Provisioner) int {
+		return cmp.Or(cmp.Compare(a.Type(), b.Type()), provisioners.ComparePrecedence(a, b))
 	})
-	return provisioners
+	return loadedProvisioners
 }
 
 func init() {
 	provisionersList.Flags().StringP("format", "f", "table", "Format of the output: table (default), json")
 	provisionersGroup.AddCommand(provisionersList)
+	provisionersGroup.AddCommand(provisionersDescribe)
+	provisionersTest.Flags().String(provisionersTestCmdFixturesFlag, "", "The directory of fixture files, defaults to the testdata directory next to the provisioners file")
+	provisionersTest.Flags().Bool(provisionersTestCmdUpdateFlag, false, "Write the golden files instead of comparing them")
+	provisionersGroup.AddCommand(provisionersTest)
 	rootCmd.AddCommand(provisionersGroup)
 }
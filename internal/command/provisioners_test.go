@@ -15,15 +15,18 @@
 package command
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/score-spec/score-k8s/internal/provisioners/loader"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -52,6 +55,20 @@ func TestDisplayProvisioners(t *testing.T) {
 			expectedResponse: "provisioners.list.valid.json.golden",
 			expectedError:    "",
 		},
+		{
+			name:             "display provisioners with match conditions in table format",
+			fixture:          "provisioners.match.golden",
+			format:           "table",
+			expectedResponse: "provisioners.list.match.table.golden",
+			expectedError:    "",
+		},
+		{
+			name:             "display provisioners with match conditions in json format",
+			fixture:          "provisioners.match.golden",
+			format:           "json",
+			expectedResponse: "provisioners.list.match.json.golden",
+			expectedError:    "",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -112,3 +129,220 @@ func goldenValue(t *testing.T, path string, goldenFile string, actual string, up
 
 	return string(content), nil
 }
+
+func TestDescribeProvisioner(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://example/postgres
+  type: postgres
+  description: A postgres database
+  supported_params: [version]
+  expected_outputs: [host]
+  params_schema:
+    type: object
+    properties:
+      version:
+        enum: ["15", "16"]
+        default: "16"
+  state: |
+    name: {{ .Id }}
+  outputs: |
+    host: {{ .State.name }}
+`), 0644))
+
+	t.Run("unknown", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "template://unknown"})
+		assert.EqualError(t, err, "no provisioner found with uri 'template://unknown'")
+	})
+
+	t.Run("nominal", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "template://example/postgres"})
+		require.NoError(t, err)
+		assert.Equal(t, `URI:         template://example/postgres
+Source:      .score-k8s/00.provisioners.yaml
+Type:        postgres
+Class:       (any)
+Id:          (any)
+Conditions:  (none)
+Description: A postgres database
+Params:      version
+Outputs:     host
+Params schema:
+  properties:
+    version:
+      default: "16"
+      enum:
+        - "15"
+        - "16"
+  type: object
+State template:
+  name: {{ .Id }}
+Outputs template:
+  host: {{ .State.name }}
+Provisioned resources:
+  (none)
+`, stdout)
+	})
+
+	t.Run("by type", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "postgres"})
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "URI:         template://example/postgres\n")
+	})
+
+	t.Run("by type and class", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "postgres.large"})
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "URI:         template://example/postgres\n")
+		stdout, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "volume"})
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "Source:      .score-k8s/zz-default.provisioners.yaml\n")
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "unknown.thing"})
+		assert.EqualError(t, err, "no provisioner matches resources of type 'unknown' and class 'thing'")
+	})
+
+	t.Run("with resources", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+  db:
+    type: postgres
+`), 0644))
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml"})
+		require.NoError(t, err)
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "postgres"})
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "Provisioned resources:\n  postgres.default#example.db (workload 'example')\n")
+	})
+
+	t.Run("with match conditions", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "01.provisioners.yaml"), []byte(`
+- uri: template://example/gold-postgres
+  type: postgres
+  priority: 5
+  match:
+    labels:
+      tier: gold
+`), 0644))
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "describe", "postgres"})
+		require.NoError(t, err)
+		gold := strings.Index(stdout, "URI:         template://example/gold-postgres\n")
+		plain := strings.Index(stdout, "URI:         template://example/postgres\n")
+		require.True(t, gold >= 0 && plain >= 0, stdout)
+		assert.Less(t, gold, plain)
+		assert.Contains(t, stdout, "Conditions:  priority=5, labels.tier=gold\n")
+	})
+}
+
+func TestTestProvisioners(t *testing.T) {
+	td := changeToTempDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "custom.provisioners.yaml"), []byte(`
+- uri: template://example/postgres
+  type: postgres
+  supported_params: [version]
+  expected_outputs: [host]
+  state: |
+    version: {{ .Params.version | default .State.version | default "15" }}
+  shared: |
+    instances: {{ add (.Shared.instances | default 0) 1 }}
+  outputs: |
+    host: {{ .SourceWorkload }}-{{ .Guid }}
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: {{ .SourceWorkload }}-db
+        namespace: {{ .Namespace }}
+      data:
+        version: {{ .State.version | quote }}
+`), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(td, "testdata"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "testdata", "nominal.fixture.yaml"), []byte(`
+input:
+  resource_uid: postgres.default#my-workload.db
+  resource_guid: 7d3c
+  resource_params:
+    version: "16"
+  source_workload: my-workload
+  namespace: my-namespace
+  shared_state:
+    instances: 2
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "testdata", "unsupported.fixture.yaml"), []byte(`
+provisioner: template://example/postgres
+input:
+  resource_uid: postgres.default#my-workload.db
+  resource_params:
+    size: large
+`), 0644))
+
+	t.Run("no fixtures", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "test", "custom.provisioners.yaml", "--fixtures", td})
+		assert.EqualError(t, err, fmt.Sprintf("no fixture files matching '*.fixture.yaml' found in '%s'", td))
+	})
+
+	t.Run("missing golden files", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "test", "custom.provisioners.yaml"})
+		assert.EqualError(t, err, "2 of 2 provisioner fixtures failed, run with --update to update the golden files")
+		assert.Contains(t, stdout, "FAIL nominal\n--- /dev/null\n+++ actual\n")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "test", "custom.provisioners.yaml", "--update"})
+		require.NoError(t, err)
+		assert.Equal(t, "UPDATED nominal\nUPDATED unsupported\n", stdout)
+
+		raw, err := os.ReadFile(filepath.Join(td, "testdata", "nominal.golden.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, `provisioner: template://example/postgres
+outputs:
+  host: my-workload-7d3c
+state:
+  version: 16
+shared:
+  instances: 3
+manifests:
+  - apiVersion: v1
+    data:
+      version: "16"
+    kind: ConfigMap
+    metadata:
+      name: my-workload-db
+      namespace: my-namespace
+`, string(raw))
+
+		raw, err = os.ReadFile(filepath.Join(td, "testdata", "unsupported.golden.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, `provisioner: template://example/postgres
+error: 'resource ''postgres.default#my-workload.db'': provisioner ''template://example/postgres'' does not support params ''size'', supported params are ''version'''
+`, string(raw))
+	})
+
+	t.Run("pass", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "test", "custom.provisioners.yaml"})
+		require.NoError(t, err)
+		assert.Equal(t, "PASS nominal\nPASS unsupported\n", stdout)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		golden := filepath.Join(td, "testdata", "nominal.golden.yaml")
+		raw, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(golden, []byte(strings.Replace(string(raw), "instances: 3", "instances: 1", 1)), 0644))
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"provisioners", "test", "custom.provisioners.yaml"})
+		assert.EqualError(t, err, "1 of 2 provisioner fixtures failed, run with --update to update the golden files")
+		assert.Contains(t, stdout, "FAIL nominal\n")
+		assert.Contains(t, stdout, "-  instances: 1\n+  instances: 3\n")
+		assert.Contains(t, stdout, "PASS unsupported\n")
+	})
+}
@@ -15,6 +15,7 @@
 package command
 
 import (
+	"errors"
 	"io"
 	"log/slog"
 
@@ -57,6 +58,20 @@ func init() {
 	rootCmd.PersistentFlags().CountP("verbose", "v", "Increase log verbosity and detail by specifying this flag one or more times")
 }
 
-func Execute() error {
-	return rootCmd.Execute()
+// Execute runs the command given on the command line and returns the exit code for the process along with any error.
+func Execute() (int, error) {
+	cmd, err := rootCmd.ExecuteC()
+	return exitCode(cmd, err), err
+}
+
+// exitCode returns the exit code for the error returned by the command. Like diff(1), the diff command exits with 1 when
+// differences are found and with 2 on any other error, so that a failure is not mistaken for changes in CI. Other
+// commands exit with 1 on any error.
+func exitCode(cmd *cobra.Command, err error) int {
+	if err == nil {
+		return 0
+	} else if cmd == diffCmd && !errors.Is(err, ErrDifferencesFound) {
+		return 2
+	}
+	return 1
 }
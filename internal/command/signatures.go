@@ -0,0 +1,110 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/score-spec/score-go/uriget"
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/score-spec/score-k8s/internal/signature"
+)
+
+// trustedKeysDirectory is the directory in the state directory that trusted public keys are loaded from.
+const trustedKeysDirectory = "trusted-keys"
+
+// verifyProvisionerFiles checks the signature of each provisioner file fetched from a remote source against the trusted
+// keys. Files from local sources are not checked, and nothing is checked when there are no trusted keys. Signature files
+// fetched along with the provisioner files from a directory are used and removed from the returned files.
+func verifyProvisionerFiles(ctx context.Context, source string, files []uriget.FileContent, keys []signature.TrustedKey, allowUnsigned bool) ([]uriget.FileContent, error) {
+	signatures := make(map[string][]byte)
+	out := make([]uriget.FileContent, 0, len(files))
+	for _, f := range files {
+		if strings.HasSuffix(f.URI, signature.Suffix) {
+			signatures[strings.TrimSuffix(f.URI, signature.Suffix)] = f.Content
+		} else {
+			out = append(out, f)
+		}
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || len(keys) == 0 {
+		return out, nil
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "git-ssh", "git-https", "oci":
+	default:
+		slog.Debug(fmt.Sprintf("Skipping signature verification of local provisioners from '%s'", source))
+		return out, nil
+	}
+
+	for _, f := range out {
+		var sigs [][]byte
+		if sig, ok := signatures[f.URI]; ok {
+			sigs = [][]byte{sig}
+		} else if f.URI == source {
+			if sigs, err = fetchSignatures(ctx, u); err != nil {
+				slog.Debug(fmt.Sprintf("Failed to fetch the signature of '%s': %v", source, err))
+			}
+		}
+		keyName, err := signature.Verify(keys, f.Content, sigs)
+		if err != nil {
+			if !allowUnsigned {
+				return nil, fmt.Errorf("failed to verify the signature of '%s': %w, use --%s to install it anyway", f.URI, err, initCmdAllowUnsignedFlag)
+			}
+			slog.Warn(fmt.Sprintf("Installing '%s' without a valid signature: %v", f.URI, err))
+			continue
+		}
+		slog.Info(fmt.Sprintf("Verified the signature of '%s' with trusted key '%s'", f.URI, keyName))
+	}
+	return out, nil
+}
+
+// fetchSignatures fetches the signatures of a single file source. OCI artifacts are signed by referrers, while other
+// sources have a detached signature next to the file.
+func fetchSignatures(ctx context.Context, u *url.URL) ([][]byte, error) {
+	if strings.ToLower(u.Scheme) == "oci" {
+		ref, err := registry.ParseReference(u.Host + u.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid artifact URL: %w", err)
+		}
+		if ref.Reference == "" {
+			ref.Reference = "latest"
+		}
+		repo, err := newOciRepository(ref)
+		if err != nil {
+			return nil, err
+		}
+		return signature.FetchReferrerSignatures(ctx, repo, ref.Reference, u.Fragment)
+	}
+
+	sigUrl := *u
+	sigUrl.Path += signature.Suffix
+	sigUrl.RawPath = ""
+	files, err := uriget.GetFiles(ctx, sigUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, len(files))
+	for i, f := range files {
+		out[i] = f.Content
+	}
+	return out, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/score-spec/score-go/formatter"
+	"github.com/score-spec/score-go/framework"
+	"github.com/spf13/cobra"
+
+	"github.com/score-spec/score-k8s/internal/project"
+	"github.com/score-spec/score-k8s/internal/provisioners"
+	"github.com/score-spec/score-k8s/internal/provisioners/loader"
+)
+
+const (
+	listWorkloadsCmdFormatFlag         = "format"
+	removeWorkloadCmdNoDeprovisionFlag = "no-deprovision"
+	removeWorkloadCmdNamespaceFlag     = "namespace"
+	removeWorkloadCmdTimeoutFlag       = "provision-timeout"
+)
+
+var (
+	workloadsGroup = &cobra.Command{
+		Use:   "workloads",
+		Short: "Subcommands related to the workloads in the project",
+	}
+	listWorkloads = &cobra.Command{
+		Use:   "list",
+		Short: "List the workloads",
+		Long: `The list command will list out the workloads in the project along with the Score file they were loaded
+from and the uids of their resources. This requires an active score-k8s state after 'init' or 'generate' has been run.
+`,
+		Args:          cobra.ExactArgs(0),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			sd, ok, err := project.LoadStateDirectory(".")
+			if err != nil {
+				return fmt.Errorf("failed to load existing state directory: %w", err)
+			} else if !ok {
+				return fmt.Errorf("state directory does not exist, please run \"score-k8s init\" first")
+			}
+
+			if len(sd.State.Workloads) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No workloads found")
+				return nil
+			}
+
+			return displayWorkloadsList(&sd.State, cmd)
+		},
+	}
+	removeWorkload = &cobra.Command{
+		Use:   "remove NAME",
+		Short: "Remove a workload from the project",
+		Long: `The remove command will remove the workload from the project state. Resources that are not referenced by
+any other workload are deprovisioned and removed along with any shared state they contributed when it is not used by
+other resources, unless --no-deprovision is set. Shared resources that are still referenced by other workloads are
+detached from the removed workload.
+
+Run 'generate' afterwards to regenerate the manifests without the workload.
+`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			sd, ok, err := project.LoadStateDirectory(".")
+			if err != nil {
+				return fmt.Errorf("failed to load existing state directory: %w", err)
+			} else if !ok {
+				return fmt.Errorf("state directory does not exist, please run \"score-k8s init\" first")
+			}
+
+			state, err := withoutWorkload(&sd.State, args[0])
+			if err != nil {
+				return err
+			}
+
+			if unreferenced := provisioners.UnreferencedResourceUids(state); len(unreferenced) > 0 {
+				if noDeprovision, _ := cmd.Flags().GetBool(removeWorkloadCmdNoDeprovisionFlag); noDeprovision {
+					slog.Info(fmt.Sprintf("Keeping %d resources that are no longer referenced by any workload", len(unreferenced)))
+				} else {
+					localProvisioners, err := loader.LoadProvisionersFromDirectory(sd.Path, loader.DefaultSuffix)
+					if err != nil {
+						return fmt.Errorf("failed to load provisioners: %w", err)
+					}
+					namespace, _ := cmd.Flags().GetString(removeWorkloadCmdNamespaceFlag)
+					timeout, _ := cmd.Flags().GetDuration(removeWorkloadCmdTimeoutFlag)
+					if timeout < 0 {
+						return fmt.Errorf("--%s must not be negative", removeWorkloadCmdTimeoutFlag)
+					}
+					if state, err = provisioners.DeprovisionResources(context.Background(), state, unreferenced, localProvisioners, namespace, timeout); err != nil {
+						return fmt.Errorf("failed to deprovision resources: %w", err)
+					}
+				}
+			}
+
+			sd.State = *state
+			if err := sd.Persist(); err != nil {
+				return fmt.Errorf("failed to persist state file: %w", err)
+			}
+			slog.Info("Persisted state file")
+			return nil
+		},
+	}
+)
+
+// workloadResourceUids returns the sorted uids of the resources declared by the workload.
+func workloadResourceUids(state *project.State, workloadName string) []framework.ResourceUid {
+	workload := state.Workloads[workloadName]
+	out := make([]framework.ResourceUid, 0, len(workload.Spec.Resources))
+	for resName, res := range workload.Spec.Resources {
+		out = append(out, framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id))
+	}
+	slices.Sort(out)
+	return out
+}
+
+// withoutWorkload returns a copy of the state with the workload removed. Shared resources that are still referenced by
+// other workloads take their source workload from a remaining workload.
+func withoutWorkload(state *project.State, workloadName string) (*project.State, error) {
+	if _, ok := state.Workloads[workloadName]; !ok {
+		return nil, fmt.Errorf("no such workload '%s'", workloadName)
+	}
+	out := *state
+	out.Workloads = maps.Clone(state.Workloads)
+	delete(out.Workloads, workloadName)
+	slog.Info(fmt.Sprintf("Removed workload '%s'", workloadName))
+	return out.WithPrimedResources()
+}
+
+func displayWorkloadsList(state *project.State, cmd *cobra.Command) error {
+	outputFormat := cmd.Flags().Lookup(listWorkloadsCmdFormatFlag).Value.String()
+	names := slices.Sorted(maps.Keys(state.Workloads))
+
+	var outputFormatter formatter.OutputFormatter
+	switch outputFormat {
+	case "json":
+		type jsonData struct {
+			Name      string
+			File      string
+			Resources []string
+		}
+		outputs := make([]jsonData, 0, len(names))
+		for _, name := range names {
+			var resources []string
+			for _, resUid := range workloadResourceUids(state, name) {
+				resources = append(resources, string(resUid))
+			}
+			var file string
+			if f := state.Workloads[name].File; f != nil {
+				file = *f
+			}
+			outputs = append(outputs, jsonData{Name: name, File: file, Resources: resources})
+		}
+		outputFormatter = &formatter.JSONOutputFormatter[[]jsonData]{Data: outputs, Out: cmd.OutOrStdout()}
+	default:
+		var rows [][]string
+		for _, name := range names {
+			var resources []string
+			for _, resUid := range workloadResourceUids(state, name) {
+				resources = append(resources, string(resUid))
+			}
+			var file string
+			if f := state.Workloads[name].File; f != nil {
+				file = *f
+			}
+			rows = append(rows, []string{name, file, strings.Join(resources, ", ")})
+		}
+		outputFormatter = &formatter.TableOutputFormatter{
+			Headers: []string{"Name", "File", "Resources"},
+			Rows:    rows,
+			Out:     cmd.OutOrStdout(),
+		}
+	}
+
+	return outputFormatter.Display()
+}
+
+func init() {
+	listWorkloads.Flags().StringP(listWorkloadsCmdFormatFlag, "f", "table", "Format of the output: table or json")
+	removeWorkload.Flags().Bool(removeWorkloadCmdNoDeprovisionFlag, false, "If true, keep the resources of the workload in the state instead of deprovisioning them")
+	removeWorkload.Flags().StringP(removeWorkloadCmdNamespaceFlag, "n", "", "An optional namespace to pass to the provisioners when deprovisioning resources")
+	removeWorkload.Flags().Duration(removeWorkloadCmdTimeoutFlag, 0, "An optional timeout, like 5m, after which deprovisioning a resource is cancelled, 0 means no timeout")
+
+	workloadsGroup.AddCommand(listWorkloads)
+	workloadsGroup.AddCommand(removeWorkload)
+
+	rootCmd.AddCommand(workloadsGroup)
+}
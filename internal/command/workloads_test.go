@@ -0,0 +1,146 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func TestWorkloadsHelp(t *testing.T) {
+	stdout, stderr, err := executeAndResetCommand(context.Background(), rootCmd, []string{"workloads", "--help"})
+	assert.NoError(t, err)
+	assert.Equal(t, `Subcommands related to the workloads in the project
+
+Usage:
+  score-k8s workloads [command]
+
+Available Commands:
+  list        List the workloads
+  remove      Remove a workload from the project
+
+Flags:
+  -h, --help   help for workloads
+
+Global Flags:
+      --quiet           Mute any logging output
+  -v, --verbose count   Increase log verbosity and detail by specifying this flag one or more times
+
+Use "score-k8s workloads [command] --help" for more information about a command.
+`, stdout)
+	assert.Equal(t, "", stderr)
+}
+
+func TestWorkloadsListAndRemove(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+
+	t.Run("list empty", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"workloads", "list"})
+		assert.NoError(t, err)
+		assert.Equal(t, "No workloads found\n", stdout)
+	})
+
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://dummy
+  type: dummy
+  shared: |
+    {{ .Uid }}: true
+    common: true
+`), 0644))
+	for _, name := range []string{"example-a", "example-b"} {
+		require.NoError(t, os.WriteFile(filepath.Join(td, name+".yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: `+name+`
+containers:
+  main:
+    image: nginx
+resources:
+  own:
+    type: dummy
+  shared:
+    type: dummy
+    id: shared
+`), 0644))
+	}
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "example-a.yaml", "example-b.yaml"})
+	require.NoError(t, err)
+
+	t.Run("list table", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"workloads", "list"})
+		assert.NoError(t, err)
+		assert.Equal(t, `+-----------+----------------+---------------------------------------------------+
+|   NAME    |      FILE      |                     RESOURCES                     |
++-----------+----------------+---------------------------------------------------+
+| example-a | example-a.yaml | dummy.default#example-a.own, dummy.default#shared |
++-----------+----------------+---------------------------------------------------+
+| example-b | example-b.yaml | dummy.default#example-b.own, dummy.default#shared |
++-----------+----------------+---------------------------------------------------+
+`, stdout)
+	})
+
+	t.Run("list json", func(t *testing.T) {
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"workloads", "list", "-f", "json"})
+		assert.NoError(t, err)
+		assert.Contains(t, stdout, `{
+    "Name": "example-a",
+    "File": "example-a.yaml",
+    "Resources": [
+      "dummy.default#example-a.own",
+      "dummy.default#shared"
+    ]
+  }`)
+	})
+
+	t.Run("remove unknown", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"workloads", "remove", "unknown"})
+		assert.EqualError(t, err, "no such workload 'unknown'")
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"workloads", "remove", "example-a"})
+		require.NoError(t, err)
+
+		sd, ok, err := project.LoadStateDirectory(".")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, []string{"example-b"}, slices.Sorted(maps.Keys(sd.State.Workloads)))
+		assert.Equal(t, []framework.ResourceUid{"dummy.default#example-b.own", "dummy.default#shared"}, slices.Sorted(maps.Keys(sd.State.Resources)))
+		assert.Equal(t, "example-b", sd.State.Resources["dummy.default#shared"].SourceWorkload)
+		assert.Equal(t, map[string]interface{}{
+			"common":                      true,
+			"dummy.default#example-b.own": true,
+			"dummy.default#shared":        true,
+		}, sd.State.SharedState)
+
+		_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate"})
+		require.NoError(t, err)
+		raw, err := os.ReadFile(filepath.Join(td, "manifests.yaml"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(raw), "example-a")
+	})
+}
@@ -0,0 +1,326 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/score-spec/score-go/framework"
+	coreV1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// ServicePortResourceType is the type of the resource that a workload uses to connect to a service port of another
+// workload. The 'workload' param is the name of the target workload and the 'port' param is the name or number of the
+// service port.
+const ServicePortResourceType = "service-port"
+
+// RouteResourceType is the type of the resource that routes external traffic, like from a Gateway, to the service port
+// of the workload in its 'port' param.
+const RouteResourceType = "route"
+
+// podControllerKinds are the kinds of resource manifests whose pods are selected by their spec.selector.matchLabels.
+var podControllerKinds = []string{WorkloadKindDeployment, WorkloadKindStatefulSet, WorkloadKindDaemonSet, "ReplicaSet"}
+
+// instanceSelector returns a label selector that selects the pods of the workload.
+func instanceSelector(state *project.State, workloadName string) machineryMeta.LabelSelector {
+	return machineryMeta.LabelSelector{MatchLabels: map[string]string{
+		SelectorLabelInstance: workloadName + state.Workloads[workloadName].Extras.InstanceSuffix,
+	}}
+}
+
+// findServicePort returns the service port of the workload with the given name or port number.
+func findServicePort(state *project.State, workloadName string, portName string) (networkingV1.NetworkPolicyPort, bool) {
+	service := state.Workloads[workloadName].Spec.Service
+	if service == nil {
+		return networkingV1.NetworkPolicyPort{}, false
+	}
+	port, ok := service.Ports[portName]
+	if !ok {
+		for _, name := range slices.Sorted(maps.Keys(service.Ports)) {
+			if strconv.Itoa(service.Ports[name].Port) == portName {
+				port, ok = service.Ports[name], true
+				break
+			}
+		}
+		if !ok {
+			return networkingV1.NetworkPolicyPort{}, false
+		}
+	}
+	proto := coreV1.ProtocolTCP
+	if port.Protocol != nil && *port.Protocol != "" {
+		proto = coreV1.Protocol(strings.ToUpper(string(*port.Protocol)))
+	}
+	return networkingV1.NetworkPolicyPort{
+		Protocol: &proto,
+		Port:     internal.Ref(intstr.FromInt32(int32(internal.DerefOr(port.TargetPort, port.Port)))),
+	}, true
+}
+
+// substitutedResourceParams returns the params of each resource of the workload with the type, in order of the
+// resource names, with the placeholders substituted.
+func substitutedResourceParams(state *project.State, workloadName string, resType string) ([]map[string]interface{}, error) {
+	spec := state.Workloads[workloadName].Spec
+	var sf func(string) (string, error)
+	var out []map[string]interface{}
+	for _, resName := range slices.Sorted(maps.Keys(spec.Resources)) {
+		res := spec.Resources[resName]
+		if res.Type != resType {
+			continue
+		}
+		params := state.Resources[framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id)].Params
+		if sf == nil {
+			resOutputs, err := state.GetResourceOutputForWorkload(workloadName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to generate outputs")
+			}
+			sf = framework.BuildSubstitutionFunction(spec.Metadata, resOutputs)
+		}
+		rawParams, err := framework.Substitute(params, sf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "resources.%s.params: failed to substitute", resName)
+		}
+		params, _ = rawParams.(map[string]interface{})
+		out = append(out, params)
+	}
+	return out, nil
+}
+
+// appendPort appends the port unless the same port and protocol is already in the list.
+func appendPort(ports []networkingV1.NetworkPolicyPort, port networkingV1.NetworkPolicyPort) []networkingV1.NetworkPolicyPort {
+	if slices.ContainsFunc(ports, func(other networkingV1.NetworkPolicyPort) bool {
+		return *other.Port == *port.Port && *other.Protocol == *port.Protocol
+	}) {
+		return ports
+	}
+	return append(ports, port)
+}
+
+// findServicePortDependencies returns the ports of the target workload that the source workload connects to through
+// its service-port resources.
+func findServicePortDependencies(state *project.State, sourceWorkload string, targetWorkload string) ([]networkingV1.NetworkPolicyPort, error) {
+	allParams, err := substitutedResourceParams(state, sourceWorkload, ServicePortResourceType)
+	if err != nil {
+		return nil, err
+	}
+	var out []networkingV1.NetworkPolicyPort
+	for _, params := range allParams {
+		if params["workload"] != targetWorkload {
+			continue
+		}
+		if port, ok := findServicePort(state, targetWorkload, fmt.Sprint(params["port"])); ok {
+			out = appendPort(out, port)
+		}
+	}
+	return out, nil
+}
+
+// findRoutedPorts returns the ports of the workload that its route resources send traffic to.
+func findRoutedPorts(state *project.State, workloadName string) ([]networkingV1.NetworkPolicyPort, error) {
+	allParams, err := substitutedResourceParams(state, workloadName, RouteResourceType)
+	if err != nil {
+		return nil, err
+	}
+	var out []networkingV1.NetworkPolicyPort
+	for _, params := range allParams {
+		if port, ok := findServicePort(state, workloadName, fmt.Sprint(params["port"])); ok {
+			out = appendPort(out, port)
+		}
+	}
+	return out, nil
+}
+
+// ConvertWorkloadNetworkPolicy returns a NetworkPolicy that denies all ingress to the pods of the workload except from
+// the workloads that connect to it through a service-port resource, on the referenced ports. Since the location of the
+// Gateway or ingress controller is not known, ingress from anywhere is allowed on the ports of its route resources.
+func ConvertWorkloadNetworkPolicy(state *project.State, workloadName string) (*networkingV1.NetworkPolicy, error) {
+	if _, ok := state.Workloads[workloadName]; !ok {
+		return nil, errors.Errorf("workload '%s' does not exist", workloadName)
+	}
+	ingress := make([]networkingV1.NetworkPolicyIngressRule, 0)
+	routedPorts, err := findRoutedPorts(state, workloadName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "workload: %s", workloadName)
+	} else if len(routedPorts) > 0 {
+		ingress = append(ingress, networkingV1.NetworkPolicyIngressRule{Ports: routedPorts})
+	}
+	for _, sourceWorkload := range slices.Sorted(maps.Keys(state.Workloads)) {
+		ports, err := findServicePortDependencies(state, sourceWorkload, workloadName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "workload: %s", sourceWorkload)
+		} else if len(ports) == 0 {
+			continue
+		}
+		ingress = append(ingress, networkingV1.NetworkPolicyIngressRule{
+			From:  []networkingV1.NetworkPolicyPeer{{PodSelector: internal.Ref(instanceSelector(state, sourceWorkload))}},
+			Ports: ports,
+		})
+	}
+	return &networkingV1.NetworkPolicy{
+		TypeMeta: machineryMeta.TypeMeta{Kind: "NetworkPolicy", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: machineryMeta.ObjectMeta{
+			Name: workloadName,
+			Annotations: map[string]string{
+				internal.AnnotationPrefix + "workload-name": workloadName,
+			},
+			Labels: map[string]string{
+				SelectorLabelName:      workloadName,
+				SelectorLabelInstance:  workloadName + state.Workloads[workloadName].Extras.InstanceSuffix,
+				SelectorLabelManagedBy: "score-k8s",
+			},
+		},
+		Spec: networkingV1.NetworkPolicySpec{
+			PodSelector: instanceSelector(state, workloadName),
+			PolicyTypes: []networkingV1.PolicyType{networkingV1.PolicyTypeIngress},
+			Ingress:     ingress,
+		},
+	}, nil
+}
+
+// findPodSelector returns the labels that select the pods of a resource manifest, and the ports of its containers.
+// It returns false when the manifest does not run any pods.
+func findPodSelector(manifest map[string]interface{}) (map[string]string, []networkingV1.NetworkPolicyPort, bool) {
+	kind, _ := manifest["kind"].(string)
+	spec, _ := manifest["spec"].(map[string]interface{})
+	var rawLabels, podSpec map[string]interface{}
+	if kind == "Pod" {
+		metadata, _ := manifest["metadata"].(map[string]interface{})
+		rawLabels, _ = metadata["labels"].(map[string]interface{})
+		podSpec = spec
+	} else if slices.Contains(podControllerKinds, kind) {
+		selector, _ := spec["selector"].(map[string]interface{})
+		rawLabels, _ = selector["matchLabels"].(map[string]interface{})
+		template, _ := spec["template"].(map[string]interface{})
+		podSpec, _ = template["spec"].(map[string]interface{})
+	}
+	if len(rawLabels) == 0 {
+		return nil, nil, false
+	}
+	labels := make(map[string]string, len(rawLabels))
+	for k, v := range rawLabels {
+		labels[k] = fmt.Sprint(v)
+	}
+
+	var ports []networkingV1.NetworkPolicyPort
+	containers, _ := podSpec["containers"].([]interface{})
+	for _, rawContainer := range containers {
+		container, _ := rawContainer.(map[string]interface{})
+		containerPorts, _ := container["ports"].([]interface{})
+		for _, rawPort := range containerPorts {
+			port, _ := rawPort.(map[string]interface{})
+			number, err := strconv.Atoi(fmt.Sprint(port["containerPort"]))
+			if err != nil {
+				continue
+			}
+			proto := coreV1.ProtocolTCP
+			if p, ok := port["protocol"].(string); ok && p != "" {
+				proto = coreV1.Protocol(p)
+			}
+			ports = append(ports, networkingV1.NetworkPolicyPort{Protocol: &proto, Port: internal.Ref(intstr.FromInt32(int32(number)))})
+		}
+	}
+	return labels, ports, true
+}
+
+// manifestKey returns the kind, namespace, and name of a manifest.
+func manifestKey(manifest map[string]interface{}) (string, string, string) {
+	kind, _ := manifest["kind"].(string)
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	return kind, namespace, name
+}
+
+// findManifestConsumers returns the workloads that use any resource that emits a manifest with the same kind,
+// namespace, and name as the given manifest, in order of the workload names. Resources that point at the same shared
+// instance emit the same manifest, so the consumers of all of them need access to its pods.
+func findManifestConsumers(state *project.State, manifest map[string]interface{}) []string {
+	key := fmt.Sprint(manifestKey(manifest))
+	var out []string
+	for _, workloadName := range slices.Sorted(maps.Keys(state.Workloads)) {
+		for resName, r := range state.Workloads[workloadName].Spec.Resources {
+			res := state.Resources[framework.NewResourceUid(workloadName, resName, r.Type, r.Class, r.Id)]
+			if slices.ContainsFunc(res.Extras.Manifests, func(other map[string]interface{}) bool {
+				return fmt.Sprint(manifestKey(other)) == key
+			}) {
+				out = append(out, workloadName)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// ConvertResourceNetworkPolicies returns a NetworkPolicy for each manifest of the resource that runs pods, like the
+// StatefulSet of a database. It denies all ingress to the pods except from the workloads that use the resource, or any
+// other resource that emits the same manifest, on the ports of the containers, or on any port when the containers do
+// not declare any. Each NetworkPolicy is named after the manifest with the lowercase kind as a suffix so that it does
+// not replace the NetworkPolicy of a workload with the same name.
+func ConvertResourceNetworkPolicies(state *project.State, resUid framework.ResourceUid) ([]*networkingV1.NetworkPolicy, error) {
+	res, ok := state.Resources[resUid]
+	if !ok {
+		return nil, errors.Errorf("resource '%s' does not exist", resUid)
+	}
+
+	var out []*networkingV1.NetworkPolicy
+	for _, manifest := range res.Extras.Manifests {
+		labels, ports, ok := findPodSelector(manifest)
+		if !ok {
+			continue
+		}
+		kind, namespace, name := manifestKey(manifest)
+		var from []networkingV1.NetworkPolicyPeer
+		for _, workloadName := range findManifestConsumers(state, manifest) {
+			from = append(from, networkingV1.NetworkPolicyPeer{PodSelector: internal.Ref(instanceSelector(state, workloadName))})
+		}
+		ingress := make([]networkingV1.NetworkPolicyIngressRule, 0, 1)
+		if len(from) > 0 {
+			ingress = append(ingress, networkingV1.NetworkPolicyIngressRule{From: from, Ports: ports})
+		}
+		out = append(out, &networkingV1.NetworkPolicy{
+			TypeMeta: machineryMeta.TypeMeta{Kind: "NetworkPolicy", APIVersion: "networking.k8s.io/v1"},
+			ObjectMeta: machineryMeta.ObjectMeta{
+				Name:      name + "-" + strings.ToLower(kind),
+				Namespace: namespace,
+				Annotations: map[string]string{
+					internal.AnnotationPrefix + "resource-uid": string(resUid),
+				},
+				Labels: map[string]string{
+					SelectorLabelManagedBy: "score-k8s",
+				},
+			},
+			Spec: networkingV1.NetworkPolicySpec{
+				PodSelector: machineryMeta.LabelSelector{MatchLabels: labels},
+				PolicyTypes: []networkingV1.PolicyType{networkingV1.PolicyTypeIngress},
+				Ingress:     ingress,
+			},
+		})
+	}
+	slices.SortFunc(out, func(a, b *networkingV1.NetworkPolicy) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return out, nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// withDependentTestWorkload adds a "web" workload to the state that depends on the ports of the "example" workload and
+// shares its database, whose backend manifests expose a port.
+func withDependentTestWorkload(t *testing.T, state *project.State) *project.State {
+	t.Helper()
+	dbId := "shared-db"
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "web"},
+		Containers: map[string]scoretypes.Container{"main": {Image: "nginx"}},
+		Resources: map[string]scoretypes.Resource{
+			"api":     {Type: ServicePortResourceType, Params: map[string]interface{}{"workload": "example", "port": "http"}},
+			"metrics": {Type: ServicePortResourceType, Params: map[string]interface{}{"workload": "example", "port": "9090"}},
+			"db":      {Type: "postgres", Id: &dbId},
+		},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-123456"})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	dbUid := framework.NewResourceUid("example", "db", "postgres", nil, &dbId)
+	db := state.Resources[dbUid]
+	db.Extras.Manifests = []map[string]interface{}{
+		{"apiVersion": "v1", "kind": "Secret", "metadata": map[string]interface{}{"name": "pg-shared"}},
+		{
+			"apiVersion": "apps/v1",
+			"kind":       "StatefulSet",
+			"metadata":   map[string]interface{}{"name": "pg-shared"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app.kubernetes.io/instance": "pg-shared"}},
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"name": "postgres", "ports": []interface{}{map[string]interface{}{"containerPort": 5432}}},
+				}}},
+			},
+		},
+	}
+	state.Resources[dbUid] = db
+	return state
+}
+
+func TestConvertWorkloadNetworkPolicy(t *testing.T) {
+	state := withDependentTestWorkload(t, buildWorkloadTestState(t, nil, &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+		"http":    {Port: 80, TargetPort: internal.Ref(8080)},
+		"metrics": {Port: 9090, Protocol: internal.Ref(scoretypes.ServicePortProtocolUDP)},
+	}}, map[string]scoretypes.Resource{
+		"db": {Type: "postgres", Id: internal.Ref("shared-db")},
+	}))
+
+	t.Run("with dependents", func(t *testing.T) {
+		policy, err := ConvertWorkloadNetworkPolicy(state, "example")
+		require.NoError(t, err)
+		out := new(bytes.Buffer)
+		require.NoError(t, internal.YamlSerializerInfo.Serializer.Encode(policy, out))
+		assert.Equal(t, `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  ingress:
+  - from:
+    - podSelector:
+        matchLabels:
+          app.kubernetes.io/instance: web-123456
+    ports:
+    - port: 8080
+      protocol: TCP
+    - port: 9090
+      protocol: UDP
+  podSelector:
+    matchLabels:
+      app.kubernetes.io/instance: example-abcdef
+  policyTypes:
+  - Ingress
+`, out.String())
+	})
+
+	t.Run("without dependents", func(t *testing.T) {
+		policy, err := ConvertWorkloadNetworkPolicy(state, "web")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{SelectorLabelInstance: "web-123456"}, policy.Spec.PodSelector.MatchLabels)
+		assert.Empty(t, policy.Spec.Ingress)
+	})
+
+	t.Run("with route", func(t *testing.T) {
+		routeState := buildWorkloadTestState(t, nil, &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+			"http":    {Port: 80, TargetPort: internal.Ref(8080)},
+			"metrics": {Port: 9090},
+		}}, map[string]scoretypes.Resource{
+			"route": {Type: RouteResourceType, Params: map[string]interface{}{"host": "example.com", "path": "/", "port": "http"}},
+		})
+		policy, err := ConvertWorkloadNetworkPolicy(routeState, "example")
+		require.NoError(t, err)
+		assert.Equal(t, []networkingV1.NetworkPolicyIngressRule{{
+			Ports: []networkingV1.NetworkPolicyPort{{Protocol: internal.Ref(coreV1.ProtocolTCP), Port: internal.Ref(intstr.FromInt32(8080))}},
+		}}, policy.Spec.Ingress)
+	})
+
+	t.Run("unknown workload", func(t *testing.T) {
+		_, err := ConvertWorkloadNetworkPolicy(state, "unknown")
+		assert.EqualError(t, err, "workload 'unknown' does not exist")
+	})
+}
+
+func TestConvertResourceNetworkPolicies(t *testing.T) {
+	state := withDependentTestWorkload(t, buildWorkloadTestState(t, nil, &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+		"http":    {Port: 80, TargetPort: internal.Ref(8080)},
+		"metrics": {Port: 9090, Protocol: internal.Ref(scoretypes.ServicePortProtocolUDP)},
+	}}, map[string]scoretypes.Resource{
+		"db": {Type: "postgres", Id: internal.Ref("shared-db")},
+	}))
+
+	policies, err := ConvertResourceNetworkPolicies(state, framework.NewResourceUid("example", "db", "postgres", nil, internal.Ref("shared-db")))
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	out := new(bytes.Buffer)
+	require.NoError(t, internal.YamlSerializerInfo.Serializer.Encode(policies[0], out))
+	assert.Equal(t, `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  annotations:
+    k8s.score.dev/resource-uid: postgres.default#shared-db
+  labels:
+    app.kubernetes.io/managed-by: score-k8s
+  name: pg-shared-statefulset
+spec:
+  ingress:
+  - from:
+    - podSelector:
+        matchLabels:
+          app.kubernetes.io/instance: example-abcdef
+    - podSelector:
+        matchLabels:
+          app.kubernetes.io/instance: web-123456
+    ports:
+    - port: 5432
+      protocol: TCP
+  podSelector:
+    matchLabels:
+      app.kubernetes.io/instance: pg-shared
+  policyTypes:
+  - Ingress
+`, out.String())
+
+	policies, err = ConvertResourceNetworkPolicies(state, framework.NewResourceUid("web", "api", ServicePortResourceType, nil, nil))
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+}
+
+func TestConvertResourceNetworkPoliciesWithSharedManifest(t *testing.T) {
+	state := buildWorkloadTestState(t, nil, nil, map[string]scoretypes.Resource{
+		"db": {Type: "postgres"},
+	})
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "web"},
+		Containers: map[string]scoretypes.Container{"main": {Image: "nginx"}},
+		Resources:  map[string]scoretypes.Resource{"db": {Type: "postgres"}},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-123456"})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	// Both resources point at the same shared instance and emit the same StatefulSet.
+	uids := []framework.ResourceUid{
+		framework.NewResourceUid("example", "db", "postgres", nil, nil),
+		framework.NewResourceUid("web", "db", "postgres", nil, nil),
+	}
+	for _, uid := range uids {
+		res := state.Resources[uid]
+		res.Extras.Manifests = []map[string]interface{}{{
+			"apiVersion": "apps/v1",
+			"kind":       "StatefulSet",
+			"metadata":   map[string]interface{}{"name": "shared-pg"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app.kubernetes.io/instance": "shared-pg"}},
+			},
+		}}
+		state.Resources[uid] = res
+	}
+
+	for _, uid := range uids {
+		policies, err := ConvertResourceNetworkPolicies(state, uid)
+		require.NoError(t, err)
+		require.Len(t, policies, 1)
+		assert.Equal(t, "shared-pg-statefulset", policies[0].Name)
+		assert.Equal(t, []networkingV1.NetworkPolicyIngressRule{{From: []networkingV1.NetworkPolicyPeer{
+			{PodSelector: &machineryMeta.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/instance": "example-abcdef"}}},
+			{PodSelector: &machineryMeta.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/instance": "web-123456"}}},
+		}}}, policies[0].Spec.Ingress, uid)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/pkg/errors"
+	autoscalingV2 "k8s.io/api/autoscaling/v2"
+	policyV1 "k8s.io/api/policy/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// ValidateDisruptionBudget checks that exactly one of min available and max unavailable is set to a valid value.
+func ValidateDisruptionBudget(budget project.DisruptionBudget) error {
+	if budget.MinAvailable != "" && budget.MaxUnavailable != "" {
+		return errors.New("only one of min available and max unavailable can be set")
+	} else if budget.MinAvailable != "" {
+		_, err := ParseIntOrPercentage(budget.MinAvailable)
+		return errors.Wrap(err, "min available")
+	} else if budget.MaxUnavailable != "" {
+		_, err := ParseIntOrPercentage(budget.MaxUnavailable)
+		return errors.Wrap(err, "max unavailable")
+	}
+	return errors.New("one of min available and max unavailable must be set")
+}
+
+// findDisruptionBudget returns the disruption budget set by the workload annotations, or nil if there is none.
+func findDisruptionBudget(specMetadata map[string]interface{}) (*policyV1.PodDisruptionBudgetSpec, error) {
+	minAvailable, hasMinAvailable := internal.FindAnnotation(specMetadata, internal.WorkloadPdbMinAvailableAnnotation)
+	maxUnavailable, hasMaxUnavailable := internal.FindAnnotation(specMetadata, internal.WorkloadPdbMaxUnavailableAnnotation)
+	out := new(policyV1.PodDisruptionBudgetSpec)
+	switch {
+	case hasMinAvailable && hasMaxUnavailable:
+		return nil, errors.Errorf("metadata: annotations: %s: cannot be set together with %s", internal.WorkloadPdbMinAvailableAnnotation, internal.WorkloadPdbMaxUnavailableAnnotation)
+	case hasMinAvailable:
+		v, err := ParseIntOrPercentage(minAvailable)
+		if err != nil {
+			return nil, errors.Wrapf(err, "metadata: annotations: %s", internal.WorkloadPdbMinAvailableAnnotation)
+		}
+		out.MinAvailable = &v
+	case hasMaxUnavailable:
+		v, err := ParseIntOrPercentage(maxUnavailable)
+		if err != nil {
+			return nil, errors.Wrapf(err, "metadata: annotations: %s", internal.WorkloadPdbMaxUnavailableAnnotation)
+		}
+		out.MaxUnavailable = &v
+	default:
+		return nil, nil
+	}
+	return out, nil
+}
+
+// buildPodDisruptionBudget returns a PodDisruptionBudget that selects the pods of the workload when the workload sets a
+// disruption budget annotation, or when the project has a default disruption budget and the workload has more than one
+// replica. An autoscaled workload has more than one replica when its minimum number of replicas is more than one.
+func buildPodDisruptionBudget(specMetadata map[string]interface{}, kind string, defaultBudget *project.DisruptionBudget, replicas *int32, hpa *autoscalingV2.HorizontalPodAutoscaler, objectMeta machineryMeta.ObjectMeta) (*policyV1.PodDisruptionBudget, error) {
+	spec, err := findDisruptionBudget(specMetadata)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil && defaultBudget != nil && (kind == WorkloadKindDeployment || kind == WorkloadKindStatefulSet) {
+		minReplicas := internal.DerefOr(replicas, 1)
+		if hpa != nil {
+			minReplicas = internal.DerefOr(hpa.Spec.MinReplicas, 1)
+		}
+		if minReplicas <= 1 {
+			return nil, nil
+		}
+		spec = new(policyV1.PodDisruptionBudgetSpec)
+		if defaultBudget.MinAvailable != "" {
+			spec.MinAvailable = internal.Ref(intstr.Parse(defaultBudget.MinAvailable))
+		} else {
+			spec.MaxUnavailable = internal.Ref(intstr.Parse(defaultBudget.MaxUnavailable))
+		}
+	} else if spec == nil {
+		return nil, nil
+	}
+
+	spec.Selector = &machineryMeta.LabelSelector{
+		MatchLabels: map[string]string{
+			SelectorLabelInstance: objectMeta.Labels[SelectorLabelInstance],
+		},
+	}
+	return &policyV1.PodDisruptionBudget{
+		TypeMeta:   machineryMeta.TypeMeta{Kind: "PodDisruptionBudget", APIVersion: "policy/v1"},
+		ObjectMeta: objectMeta,
+		Spec:       *spec,
+	}, nil
+}
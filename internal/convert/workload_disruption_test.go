@@ -0,0 +1,129 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func TestConvertPodDisruptionBudget(t *testing.T) {
+	out, err := encodeManifests(t, buildWorkloadTestState(t, map[string]interface{}{
+		internal.WorkloadPdbMinAvailableAnnotation: "50%",
+	}, nil, nil))
+	require.NoError(t, err)
+	assert.Contains(t, out, `---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  minAvailable: 50%
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: example-abcdef
+status:
+  currentHealthy: 0
+  desiredHealthy: 0
+  disruptionsAllowed: 0
+  expectedPods: 0
+---
+`)
+}
+
+func TestConvertPodDisruptionBudget_default(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annotations map[string]interface{}
+		expected    string
+	}{
+		"single replica": {
+			annotations: map[string]interface{}{},
+		},
+		"replicas": {
+			annotations: map[string]interface{}{internal.WorkloadReplicasAnnotation: "3"},
+			expected:    "maxUnavailable: 1",
+		},
+		"single min replica": {
+			annotations: map[string]interface{}{internal.WorkloadMaxReplicasAnnotation: "4"},
+		},
+		"min replicas": {
+			annotations: map[string]interface{}{internal.WorkloadMinReplicasAnnotation: "2", internal.WorkloadMaxReplicasAnnotation: "4"},
+			expected:    "maxUnavailable: 1",
+		},
+		"annotation overrides default": {
+			annotations: map[string]interface{}{internal.WorkloadPdbMinAvailableAnnotation: "1"},
+			expected:    "minAvailable: 1",
+		},
+		"daemonset": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindDaemonSet},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			state := buildWorkloadTestState(t, tc.annotations, nil, nil)
+			state.Extras.DefaultDisruptionBudget = &project.DisruptionBudget{MaxUnavailable: "1"}
+			out, err := encodeManifests(t, state)
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.NotContains(t, out, "PodDisruptionBudget")
+			} else {
+				assert.Contains(t, out, "kind: PodDisruptionBudget")
+				assert.Contains(t, out, "\n  "+tc.expected+"\n")
+			}
+		})
+	}
+}
+
+func TestConvertPodDisruptionBudget_errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annotations map[string]interface{}
+		err         string
+	}{
+		"on job": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindJob, internal.WorkloadPdbMaxUnavailableAnnotation: "1"},
+			err:         "metadata: annotations: k8s.score.dev/pdb-max-unavailable: not supported by workload kind Job",
+		},
+		"both": {
+			annotations: map[string]interface{}{internal.WorkloadPdbMinAvailableAnnotation: "1", internal.WorkloadPdbMaxUnavailableAnnotation: "1"},
+			err:         "metadata: annotations: k8s.score.dev/pdb-min-available: cannot be set together with k8s.score.dev/pdb-max-unavailable",
+		},
+		"bad value": {
+			annotations: map[string]interface{}{internal.WorkloadPdbMaxUnavailableAnnotation: "-1"},
+			err:         "metadata: annotations: k8s.score.dev/pdb-max-unavailable: expected a non-negative integer or percentage but got '-1'",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := encodeManifests(t, buildWorkloadTestState(t, tc.annotations, nil, nil))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestValidateDisruptionBudget(t *testing.T) {
+	assert.NoError(t, ValidateDisruptionBudget(project.DisruptionBudget{MinAvailable: "2"}))
+	assert.NoError(t, ValidateDisruptionBudget(project.DisruptionBudget{MaxUnavailable: "25%"}))
+	assert.EqualError(t, ValidateDisruptionBudget(project.DisruptionBudget{}), "one of min available and max unavailable must be set")
+	assert.EqualError(t, ValidateDisruptionBudget(project.DisruptionBudget{MinAvailable: "1", MaxUnavailable: "1"}), "only one of min available and max unavailable can be set")
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// buildJobSpec converts the job-related workload annotations into a job spec wrapping the given pod template. Jobs
+// require a restart policy of OnFailure or Never, so we default to OnFailure.
+func buildJobSpec(specMetadata map[string]interface{}, podTemplate coreV1.PodTemplateSpec) (*batchV1.JobSpec, error) {
+	out := &batchV1.JobSpec{Template: podTemplate}
+
+	out.Template.Spec.RestartPolicy = coreV1.RestartPolicyOnFailure
+	if d, ok := internal.FindAnnotation(specMetadata, internal.WorkloadRestartPolicyAnnotation); ok {
+		if !slices.Contains([]string{string(coreV1.RestartPolicyOnFailure), string(coreV1.RestartPolicyNever)}, d) {
+			return nil, errors.Errorf("metadata: annotations: %s: expected OnFailure or Never but got '%s'", internal.WorkloadRestartPolicyAnnotation, d)
+		}
+		out.Template.Spec.RestartPolicy = coreV1.RestartPolicy(d)
+	}
+
+	var err error
+	if out.BackoffLimit, err = findInt32Annotation(specMetadata, internal.WorkloadBackoffLimitAnnotation); err != nil {
+		return nil, err
+	}
+	if out.Completions, err = findInt32Annotation(specMetadata, internal.WorkloadCompletionsAnnotation); err != nil {
+		return nil, err
+	}
+	if out.Parallelism, err = findInt32Annotation(specMetadata, internal.WorkloadParallelismAnnotation); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// buildCronJobSpec builds a cron job spec from the schedule and concurrency policy annotations. The schedule is required
+// since Kubernetes has no default for it.
+func buildCronJobSpec(specMetadata map[string]interface{}, labels map[string]string, podTemplate coreV1.PodTemplateSpec) (*batchV1.CronJobSpec, error) {
+	schedule, ok := internal.FindAnnotation(specMetadata, internal.WorkloadScheduleAnnotation)
+	if !ok || schedule == "" {
+		return nil, errors.Errorf("metadata: annotations: %s: a schedule is required for workload kind %s", internal.WorkloadScheduleAnnotation, WorkloadKindCronJob)
+	}
+	jobSpec, err := buildJobSpec(specMetadata, podTemplate)
+	if err != nil {
+		return nil, err
+	}
+	out := &batchV1.CronJobSpec{
+		Schedule: schedule,
+		JobTemplate: batchV1.JobTemplateSpec{
+			ObjectMeta: machineryMeta.ObjectMeta{Labels: labels},
+			Spec:       *jobSpec,
+		},
+	}
+	if d, ok := internal.FindAnnotation(specMetadata, internal.WorkloadConcurrencyPolicyAnnotation); ok {
+		if !slices.Contains([]string{string(batchV1.AllowConcurrent), string(batchV1.ForbidConcurrent), string(batchV1.ReplaceConcurrent)}, d) {
+			return nil, errors.Errorf("metadata: annotations: %s: expected Allow, Forbid, or Replace but got '%s'", internal.WorkloadConcurrencyPolicyAnnotation, d)
+		}
+		out.ConcurrencyPolicy = batchV1.ConcurrencyPolicy(d)
+	}
+	return out, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	scoretypes "github.com/score-spec/score-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func TestConvertJob(t *testing.T) {
+	out, err := encodeManifests(t, buildWorkloadTestState(t, map[string]interface{}{
+		internal.WorkloadKindAnnotation:          WorkloadKindJob,
+		internal.WorkloadBackoffLimitAnnotation:  "2",
+		internal.WorkloadCompletionsAnnotation:   "3",
+		internal.WorkloadParallelismAnnotation:   "1",
+		internal.WorkloadRestartPolicyAnnotation: "Never",
+	}, nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+automountServiceAccountToken: false
+kind: ServiceAccount
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  backoffLimit: 2
+  completions: 3
+  parallelism: 1
+  template:
+    metadata:
+      annotations:
+        k8s.score.dev/workload-name: example
+      labels:
+        app.kubernetes.io/instance: example-abcdef
+        app.kubernetes.io/managed-by: score-k8s
+        app.kubernetes.io/name: example
+    spec:
+      automountServiceAccountToken: false
+      containers:
+      - command:
+        - echo
+        - hello
+        image: busybox
+        name: main
+        resources: {}
+      restartPolicy: Never
+      serviceAccountName: example
+status: {}
+---
+`, out)
+}
+
+func TestConvertCronJob(t *testing.T) {
+	out, err := encodeManifests(t, buildWorkloadTestState(t, map[string]interface{}{
+		internal.WorkloadKindAnnotation:              WorkloadKindCronJob,
+		internal.WorkloadScheduleAnnotation:          "0 3 * * *",
+		internal.WorkloadConcurrencyPolicyAnnotation: "Forbid",
+	}, nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+automountServiceAccountToken: false
+kind: ServiceAccount
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  concurrencyPolicy: Forbid
+  jobTemplate:
+    metadata:
+      labels:
+        app.kubernetes.io/instance: example-abcdef
+        app.kubernetes.io/managed-by: score-k8s
+        app.kubernetes.io/name: example
+    spec:
+      template:
+        metadata:
+          annotations:
+            k8s.score.dev/workload-name: example
+          labels:
+            app.kubernetes.io/instance: example-abcdef
+            app.kubernetes.io/managed-by: score-k8s
+            app.kubernetes.io/name: example
+        spec:
+          automountServiceAccountToken: false
+          containers:
+          - command:
+            - echo
+            - hello
+            image: busybox
+            name: main
+            resources: {}
+          restartPolicy: OnFailure
+          serviceAccountName: example
+  schedule: 0 3 * * *
+status: {}
+---
+`, out)
+}
+
+func TestConvertJob_errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annotations map[string]interface{}
+		service     *scoretypes.WorkloadService
+		err         string
+	}{
+		"unknown kind": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: "ReplicaSet"},
+			err:         "metadata: annotations: k8s.score.dev/kind: unsupported workload kind 'ReplicaSet'",
+		},
+		"cron job without schedule": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindCronJob},
+			err:         "metadata: annotations: k8s.score.dev/schedule: a schedule is required for workload kind CronJob",
+		},
+		"bad restart policy": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindJob, internal.WorkloadRestartPolicyAnnotation: "Always"},
+			err:         "metadata: annotations: k8s.score.dev/restart-policy: expected OnFailure or Never but got 'Always'",
+		},
+		"bad backoff limit": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindJob, internal.WorkloadBackoffLimitAnnotation: "-1"},
+			err:         "metadata: annotations: k8s.score.dev/backoff-limit: expected a non-negative integer but got '-1'",
+		},
+		"bad concurrency policy": {
+			annotations: map[string]interface{}{
+				internal.WorkloadKindAnnotation:              WorkloadKindCronJob,
+				internal.WorkloadScheduleAnnotation:          "@daily",
+				internal.WorkloadConcurrencyPolicyAnnotation: "Sometimes",
+			},
+			err: "metadata: annotations: k8s.score.dev/concurrency-policy: expected Allow, Forbid, or Replace but got 'Sometimes'",
+		},
+		"job with service": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindJob},
+			service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+				"web": {Port: 80},
+			}},
+			err: "service: ports cannot be exposed by workload kind Job",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := encodeManifests(t, buildWorkloadTestState(t, tc.annotations, tc.service, nil))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
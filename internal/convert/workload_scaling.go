@@ -0,0 +1,148 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	autoscalingV2 "k8s.io/api/autoscaling/v2"
+	coreV1 "k8s.io/api/core/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// scalingAnnotations are the annotations that are only supported by the Deployment and StatefulSet workload kinds.
+var scalingAnnotations = []string{
+	internal.WorkloadReplicasAnnotation,
+	internal.WorkloadMinReplicasAnnotation,
+	internal.WorkloadMaxReplicasAnnotation,
+	internal.WorkloadTargetCPUUtilizationAnnotation,
+	internal.WorkloadTargetMemoryUtilizationAnnotation,
+	internal.WorkloadPdbMinAvailableAnnotation,
+	internal.WorkloadPdbMaxUnavailableAnnotation,
+}
+
+// checkScalingAnnotations returns an error when any of the scaling annotations is set on a workload kind that does not
+// support them.
+func checkScalingAnnotations(specMetadata map[string]interface{}, kind string) error {
+	if kind == WorkloadKindDeployment || kind == WorkloadKindStatefulSet {
+		return nil
+	}
+	for _, annotation := range scalingAnnotations {
+		if _, ok := internal.FindAnnotation(specMetadata, annotation); ok {
+			return errors.Errorf("metadata: annotations: %s: not supported by workload kind %s", annotation, kind)
+		}
+	}
+	return nil
+}
+
+// buildReplicas returns the fixed number of replicas when the replicas annotation is set.
+func buildReplicas(specMetadata map[string]interface{}) (*int32, error) {
+	replicas, err := findInt32Annotation(specMetadata, internal.WorkloadReplicasAnnotation)
+	if err != nil || replicas == nil {
+		return nil, err
+	}
+	if _, ok := internal.FindAnnotation(specMetadata, internal.WorkloadMaxReplicasAnnotation); ok {
+		return nil, errors.Errorf("metadata: annotations: %s: cannot be set together with %s", internal.WorkloadReplicasAnnotation, internal.WorkloadMaxReplicasAnnotation)
+	}
+	return replicas, nil
+}
+
+// findUtilizationAnnotation parses the annotation as a positive percentage with an optional % suffix. It returns nil if
+// the annotation is not set.
+func findUtilizationAnnotation(specMetadata map[string]interface{}, annotation string) (*int32, error) {
+	d, ok := internal.FindAnnotation(specMetadata, annotation)
+	if !ok {
+		return nil, nil
+	}
+	v, err := strconv.ParseInt(strings.TrimSuffix(d, "%"), 10, 32)
+	if err != nil || v < 1 {
+		return nil, errors.Errorf("metadata: annotations: %s: expected a positive percentage but got '%s'", annotation, d)
+	}
+	return internal.Ref(int32(v)), nil
+}
+
+// buildHorizontalPodAutoscaler returns an autoscaling/v2 HorizontalPodAutoscaler for the workload when the max-replicas
+// annotation is set, with a resource utilization metric for each of the cpu and memory targets that are set. Without
+// any targets, Kubernetes defaults to a cpu utilization of 80%.
+func buildHorizontalPodAutoscaler(specMetadata map[string]interface{}, kind string, objectMeta machineryMeta.ObjectMeta) (*autoscalingV2.HorizontalPodAutoscaler, error) {
+	maxReplicas, err := findInt32Annotation(specMetadata, internal.WorkloadMaxReplicasAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	minReplicas, err := findInt32Annotation(specMetadata, internal.WorkloadMinReplicasAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	cpuTarget, err := findUtilizationAnnotation(specMetadata, internal.WorkloadTargetCPUUtilizationAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	memoryTarget, err := findUtilizationAnnotation(specMetadata, internal.WorkloadTargetMemoryUtilizationAnnotation)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxReplicas == nil {
+		for _, annotation := range []string{internal.WorkloadMinReplicasAnnotation, internal.WorkloadTargetCPUUtilizationAnnotation, internal.WorkloadTargetMemoryUtilizationAnnotation} {
+			if _, ok := internal.FindAnnotation(specMetadata, annotation); ok {
+				return nil, errors.Errorf("metadata: annotations: %s: requires %s to be set", annotation, internal.WorkloadMaxReplicasAnnotation)
+			}
+		}
+		return nil, nil
+	} else if *maxReplicas < 1 {
+		return nil, errors.Errorf("metadata: annotations: %s: must be at least 1", internal.WorkloadMaxReplicasAnnotation)
+	} else if minReplicas != nil && (*minReplicas < 1 || *minReplicas > *maxReplicas) {
+		return nil, errors.Errorf("metadata: annotations: %s: must be between 1 and %s (%d)", internal.WorkloadMinReplicasAnnotation, internal.WorkloadMaxReplicasAnnotation, *maxReplicas)
+	}
+
+	var metrics []autoscalingV2.MetricSpec
+	for _, target := range []struct {
+		name        coreV1.ResourceName
+		utilization *int32
+	}{{coreV1.ResourceCPU, cpuTarget}, {coreV1.ResourceMemory, memoryTarget}} {
+		if target.utilization == nil {
+			continue
+		}
+		metrics = append(metrics, autoscalingV2.MetricSpec{
+			Type: autoscalingV2.ResourceMetricSourceType,
+			Resource: &autoscalingV2.ResourceMetricSource{
+				Name: target.name,
+				Target: autoscalingV2.MetricTarget{
+					Type:               autoscalingV2.UtilizationMetricType,
+					AverageUtilization: target.utilization,
+				},
+			},
+		})
+	}
+
+	return &autoscalingV2.HorizontalPodAutoscaler{
+		TypeMeta:   machineryMeta.TypeMeta{Kind: "HorizontalPodAutoscaler", APIVersion: "autoscaling/v2"},
+		ObjectMeta: objectMeta,
+		Spec: autoscalingV2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingV2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       kind,
+				Name:       objectMeta.Name,
+			},
+			MinReplicas: minReplicas,
+			MaxReplicas: *maxReplicas,
+			Metrics:     metrics,
+		},
+	}, nil
+}
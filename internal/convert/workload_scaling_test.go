@@ -0,0 +1,133 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func TestConvertReplicas(t *testing.T) {
+	out, err := encodeManifests(t, buildWorkloadTestState(t, map[string]interface{}{
+		internal.WorkloadKindAnnotation:     WorkloadKindStatefulSet,
+		internal.WorkloadReplicasAnnotation: "3",
+	}, nil, nil))
+	require.NoError(t, err)
+	assert.Contains(t, out, `kind: StatefulSet
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  replicas: 3
+`)
+	assert.NotContains(t, out, "HorizontalPodAutoscaler")
+}
+
+func TestConvertHorizontalPodAutoscaler(t *testing.T) {
+	out, err := encodeManifests(t, buildWorkloadTestState(t, map[string]interface{}{
+		internal.WorkloadMinReplicasAnnotation:             "2",
+		internal.WorkloadMaxReplicasAnnotation:             "10",
+		internal.WorkloadTargetCPUUtilizationAnnotation:    "75%",
+		internal.WorkloadTargetMemoryUtilizationAnnotation: "80",
+	}, nil, nil))
+	require.NoError(t, err)
+	assert.NotContains(t, out, "replicas: ")
+	assert.Contains(t, out, `---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  maxReplicas: 10
+  metrics:
+  - resource:
+      name: cpu
+      target:
+        averageUtilization: 75
+        type: Utilization
+    type: Resource
+  - resource:
+      name: memory
+      target:
+        averageUtilization: 80
+        type: Utilization
+    type: Resource
+  minReplicas: 2
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: example
+status:
+  currentMetrics: null
+  desiredReplicas: 0
+---
+`)
+}
+
+func TestConvertScaling_errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annotations map[string]interface{}
+		err         string
+	}{
+		"replicas on job": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindJob, internal.WorkloadReplicasAnnotation: "2"},
+			err:         "metadata: annotations: k8s.score.dev/replicas: not supported by workload kind Job",
+		},
+		"bad replicas": {
+			annotations: map[string]interface{}{internal.WorkloadReplicasAnnotation: "many"},
+			err:         "metadata: annotations: k8s.score.dev/replicas: expected a non-negative integer but got 'many'",
+		},
+		"replicas and max replicas": {
+			annotations: map[string]interface{}{internal.WorkloadReplicasAnnotation: "2", internal.WorkloadMaxReplicasAnnotation: "4"},
+			err:         "metadata: annotations: k8s.score.dev/replicas: cannot be set together with k8s.score.dev/max-replicas",
+		},
+		"target without max replicas": {
+			annotations: map[string]interface{}{internal.WorkloadTargetCPUUtilizationAnnotation: "50"},
+			err:         "metadata: annotations: k8s.score.dev/target-cpu-utilization: requires k8s.score.dev/max-replicas to be set",
+		},
+		"zero max replicas": {
+			annotations: map[string]interface{}{internal.WorkloadMaxReplicasAnnotation: "0"},
+			err:         "metadata: annotations: k8s.score.dev/max-replicas: must be at least 1",
+		},
+		"min above max": {
+			annotations: map[string]interface{}{internal.WorkloadMinReplicasAnnotation: "5", internal.WorkloadMaxReplicasAnnotation: "4"},
+			err:         "metadata: annotations: k8s.score.dev/min-replicas: must be between 1 and k8s.score.dev/max-replicas (4)",
+		},
+		"bad target": {
+			annotations: map[string]interface{}{internal.WorkloadMaxReplicasAnnotation: "4", internal.WorkloadTargetMemoryUtilizationAnnotation: "0%"},
+			err:         "metadata: annotations: k8s.score.dev/target-memory-utilization: expected a positive percentage but got '0%'",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := encodeManifests(t, buildWorkloadTestState(t, tc.annotations, nil, nil))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
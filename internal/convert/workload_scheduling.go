@@ -0,0 +1,83 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// buildNodeSelector parses the node selector annotation as a comma-separated list of key=value pairs.
+func buildNodeSelector(specMetadata map[string]interface{}) (map[string]string, error) {
+	d, ok := internal.FindAnnotation(specMetadata, internal.WorkloadNodeSelectorAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	out := make(map[string]string)
+	for _, pair := range strings.Split(d, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, errors.Errorf("metadata: annotations: %s: expected comma-separated key=value pairs but got '%s'", internal.WorkloadNodeSelectorAnnotation, d)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+// buildTolerations decodes the tolerations annotation as a yaml or json encoded list of Kubernetes tolerations.
+func buildTolerations(specMetadata map[string]interface{}) ([]coreV1.Toleration, error) {
+	d, ok := internal.FindAnnotation(specMetadata, internal.WorkloadTolerationsAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var out []coreV1.Toleration
+	if err := yaml.UnmarshalStrict([]byte(d), &out); err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations: %s: failed to decode tolerations", internal.WorkloadTolerationsAnnotation)
+	}
+	return out, nil
+}
+
+// ParseIntOrPercentage parses a non-negative number or percentage, like 1 or 25%.
+func ParseIntOrPercentage(value string) (intstr.IntOrString, error) {
+	if v, err := strconv.Atoi(strings.TrimSuffix(value, "%")); err != nil || v < 0 {
+		return intstr.IntOrString{}, errors.Errorf("expected a non-negative integer or percentage but got '%s'", value)
+	}
+	return intstr.Parse(value), nil
+}
+
+// buildDaemonSetUpdateStrategy returns a rolling update strategy when the max-unavailable annotation is set, this may
+// be an absolute number of nodes or a percentage.
+func buildDaemonSetUpdateStrategy(specMetadata map[string]interface{}) (v1.DaemonSetUpdateStrategy, error) {
+	d, ok := internal.FindAnnotation(specMetadata, internal.WorkloadMaxUnavailableAnnotation)
+	if !ok {
+		return v1.DaemonSetUpdateStrategy{}, nil
+	}
+	maxUnavailable, err := ParseIntOrPercentage(d)
+	if err != nil {
+		return v1.DaemonSetUpdateStrategy{}, errors.Wrapf(err, "metadata: annotations: %s", internal.WorkloadMaxUnavailableAnnotation)
+	}
+	return v1.DaemonSetUpdateStrategy{
+		Type:          v1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &v1.RollingUpdateDaemonSet{MaxUnavailable: &maxUnavailable},
+	}, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func TestConvertDaemonSet(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				internal.WorkloadKindAnnotation:           WorkloadKindDaemonSet,
+				internal.WorkloadNodeSelectorAnnotation:   "kubernetes.io/os=linux, tier=edge",
+				internal.WorkloadTolerationsAnnotation:    `[{"key": "node-role.kubernetes.io/control-plane", "operator": "Exists", "effect": "NoSchedule"}]`,
+				internal.WorkloadMaxUnavailableAnnotation: "25%",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"main": {
+				Image: "fluent-bit",
+				Volumes: map[string]scoretypes.ContainerVolume{
+					"/var/log": {Source: "${resources.logs}", ReadOnly: internal.Ref(true)},
+				},
+			},
+		},
+		Resources: map[string]scoretypes.Resource{
+			"logs": {Type: "volume"},
+		},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abcdef"})
+	require.NoError(t, err)
+	state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+		"volume.default#example.logs": {
+			Type:  "volume",
+			Class: "default",
+			Outputs: map[string]interface{}{
+				"source": map[string]interface{}{
+					"hostPath": map[string]interface{}{"path": "/var/log", "type": "Directory"},
+				},
+			},
+		},
+	}
+
+	out, err := encodeManifests(t, state)
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+automountServiceAccountToken: false
+kind: ServiceAccount
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: example-abcdef
+  template:
+    metadata:
+      annotations:
+        k8s.score.dev/workload-name: example
+      labels:
+        app.kubernetes.io/instance: example-abcdef
+        app.kubernetes.io/managed-by: score-k8s
+        app.kubernetes.io/name: example
+    spec:
+      automountServiceAccountToken: false
+      containers:
+      - image: fluent-bit
+        name: main
+        resources: {}
+        volumeMounts:
+        - mountPath: /var/log
+          name: vol-9a6a409e26
+          readOnly: true
+      nodeSelector:
+        kubernetes.io/os: linux
+        tier: edge
+      serviceAccountName: example
+      tolerations:
+      - effect: NoSchedule
+        key: node-role.kubernetes.io/control-plane
+        operator: Exists
+      volumes:
+      - hostPath:
+          path: /var/log
+          type: Directory
+        name: vol-9a6a409e26
+  updateStrategy:
+    rollingUpdate:
+      maxUnavailable: 25%
+    type: RollingUpdate
+status:
+  currentNumberScheduled: 0
+  desiredNumberScheduled: 0
+  numberMisscheduled: 0
+  numberReady: 0
+---
+`, out)
+}
+
+func TestConvertDaemonSet_errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annotations map[string]interface{}
+		err         string
+	}{
+		"bad node selector": {
+			annotations: map[string]interface{}{internal.WorkloadNodeSelectorAnnotation: "linux"},
+			err:         "metadata: annotations: k8s.score.dev/node-selector: expected comma-separated key=value pairs but got 'linux'",
+		},
+		"bad tolerations": {
+			annotations: map[string]interface{}{internal.WorkloadTolerationsAnnotation: `[{"unknown": "field"}]`},
+			err:         "metadata: annotations: k8s.score.dev/tolerations: failed to decode tolerations: error unmarshaling JSON: while decoding JSON: json: unknown field \"unknown\"",
+		},
+		"bad max unavailable": {
+			annotations: map[string]interface{}{internal.WorkloadKindAnnotation: WorkloadKindDaemonSet, internal.WorkloadMaxUnavailableAnnotation: "some"},
+			err:         "metadata: annotations: k8s.score.dev/max-unavailable: expected a non-negative integer or percentage but got 'some'",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := encodeManifests(t, buildWorkloadTestState(t, tc.annotations, nil, nil))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
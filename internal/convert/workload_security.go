@@ -0,0 +1,177 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// SecurityProfile is a named set of security context settings that are applied to the pods of each workload.
+type SecurityProfile string
+
+const (
+	// SecurityProfileNone does not set any security context settings.
+	SecurityProfileNone SecurityProfile = "none"
+	// SecurityProfileBaseline disables privilege escalation and uses the RuntimeDefault seccomp profile. These settings
+	// work with most container images.
+	SecurityProfileBaseline SecurityProfile = "baseline"
+	// SecurityProfileRestricted meets the restricted Pod Security Standard. On top of the baseline settings, the
+	// containers must run as a non-root user, drop all capabilities, and use a read-only root filesystem.
+	SecurityProfileRestricted SecurityProfile = "restricted"
+)
+
+// SecurityProfiles are the supported security profiles.
+var SecurityProfiles = []SecurityProfile{SecurityProfileNone, SecurityProfileBaseline, SecurityProfileRestricted}
+
+// securitySettings are the security context settings of a workload after applying its annotations to the profile.
+type securitySettings struct {
+	runAsNonRoot             *bool
+	seccompProfile           *coreV1.SeccompProfileType
+	allowPrivilegeEscalation *bool
+	readOnlyRootFilesystem   *bool
+	dropAllCapabilities      bool
+	addCapabilities          []coreV1.Capability
+	// report describes each of the settings in the order they were set, and which annotation set it, if any.
+	report []string
+}
+
+// findBoolAnnotation parses the annotation as a boolean. It returns nil if the annotation is not set.
+func findBoolAnnotation(specMetadata map[string]interface{}, annotation string) (*bool, error) {
+	if d, ok := internal.FindAnnotation(specMetadata, annotation); ok {
+		v, err := strconv.ParseBool(d)
+		if err != nil {
+			return nil, errors.Errorf("metadata: annotations: %s: expected true or false but got '%s'", annotation, d)
+		}
+		return &v, nil
+	}
+	return nil, nil
+}
+
+// capabilityNames returns the names of the capabilities.
+func capabilityNames(capabilities []coreV1.Capability) []string {
+	out := make([]string, len(capabilities))
+	for i, c := range capabilities {
+		out[i] = string(c)
+	}
+	return out
+}
+
+// buildSecuritySettings returns the security context settings of the profile, with each setting overridden by its
+// workload annotation when that is set.
+func buildSecuritySettings(specMetadata map[string]interface{}, profile SecurityProfile) (*securitySettings, error) {
+	out := new(securitySettings)
+	switch profile {
+	case SecurityProfileRestricted:
+		out.runAsNonRoot = internal.Ref(true)
+		out.readOnlyRootFilesystem = internal.Ref(true)
+		out.dropAllCapabilities = true
+		fallthrough
+	case SecurityProfileBaseline:
+		out.allowPrivilegeEscalation = internal.Ref(false)
+		out.seccompProfile = internal.Ref(coreV1.SeccompProfileTypeRuntimeDefault)
+	case SecurityProfileNone, "":
+	default:
+		return nil, errors.Errorf("unknown security profile '%s'", profile)
+	}
+
+	for _, setting := range []struct {
+		name       string
+		annotation string
+		value      **bool
+	}{
+		{"runAsNonRoot", internal.WorkloadRunAsNonRootAnnotation, &out.runAsNonRoot},
+		{"allowPrivilegeEscalation", internal.WorkloadAllowPrivilegeEscalationAnnotation, &out.allowPrivilegeEscalation},
+		{"readOnlyRootFilesystem", internal.WorkloadReadOnlyRootFilesystemAnnotation, &out.readOnlyRootFilesystem},
+	} {
+		v, err := findBoolAnnotation(specMetadata, setting.annotation)
+		if err != nil {
+			return nil, err
+		} else if v != nil {
+			*setting.value = v
+			out.report = append(out.report, fmt.Sprintf("%s=%t (%s)", setting.name, *v, setting.annotation))
+		} else if *setting.value != nil {
+			out.report = append(out.report, fmt.Sprintf("%s=%t", setting.name, **setting.value))
+		}
+	}
+
+	if out.dropAllCapabilities {
+		out.report = append(out.report, "capabilities.drop=ALL")
+	}
+	if d, ok := internal.FindAnnotation(specMetadata, internal.WorkloadAddCapabilitiesAnnotation); ok {
+		for _, c := range strings.Split(d, ",") {
+			if c = strings.ToUpper(strings.TrimSpace(c)); c == "" {
+				return nil, errors.Errorf("metadata: annotations: %s: expected a comma-separated list of capabilities but got '%s'", internal.WorkloadAddCapabilitiesAnnotation, d)
+			}
+			out.addCapabilities = append(out.addCapabilities, coreV1.Capability(c))
+		}
+		out.report = append(out.report, fmt.Sprintf("capabilities.add=%s (%s)", strings.Join(capabilityNames(out.addCapabilities), ","), internal.WorkloadAddCapabilitiesAnnotation))
+	}
+
+	if d, ok := internal.FindAnnotation(specMetadata, internal.WorkloadSeccompProfileAnnotation); ok {
+		switch v := coreV1.SeccompProfileType(d); v {
+		case coreV1.SeccompProfileTypeRuntimeDefault, coreV1.SeccompProfileTypeUnconfined:
+			out.seccompProfile = &v
+		default:
+			return nil, errors.Errorf("metadata: annotations: %s: expected %s or %s but got '%s'", internal.WorkloadSeccompProfileAnnotation, coreV1.SeccompProfileTypeRuntimeDefault, coreV1.SeccompProfileTypeUnconfined, d)
+		}
+		out.report = append(out.report, fmt.Sprintf("seccompProfile=%s (%s)", d, internal.WorkloadSeccompProfileAnnotation))
+	} else if out.seccompProfile != nil {
+		out.report = append(out.report, fmt.Sprintf("seccompProfile=%s", *out.seccompProfile))
+	}
+	return out, nil
+}
+
+// apply sets the pod security context and the security context of each container in the pod spec.
+func (s *securitySettings) apply(podSpec *coreV1.PodSpec) {
+	if s.runAsNonRoot != nil || s.seccompProfile != nil {
+		podSpec.SecurityContext = &coreV1.PodSecurityContext{RunAsNonRoot: s.runAsNonRoot}
+		if s.seccompProfile != nil {
+			podSpec.SecurityContext.SeccompProfile = &coreV1.SeccompProfile{Type: *s.seccompProfile}
+		}
+	}
+	if s.allowPrivilegeEscalation == nil && s.readOnlyRootFilesystem == nil && !s.dropAllCapabilities && len(s.addCapabilities) == 0 {
+		return
+	}
+	for i := range podSpec.Containers {
+		sc := &coreV1.SecurityContext{
+			AllowPrivilegeEscalation: s.allowPrivilegeEscalation,
+			ReadOnlyRootFilesystem:   s.readOnlyRootFilesystem,
+		}
+		if s.dropAllCapabilities || len(s.addCapabilities) > 0 {
+			sc.Capabilities = &coreV1.Capabilities{Add: s.addCapabilities}
+			if s.dropAllCapabilities {
+				sc.Capabilities.Drop = []coreV1.Capability{"ALL"}
+			}
+		}
+		podSpec.Containers[i].SecurityContext = sc
+	}
+}
+
+// DescribeSecuritySettings returns a description of each security context setting that ConvertWorkload sets on the pods
+// of the workload with the security profile, like "runAsNonRoot=true", along with the annotation that set it, if any.
+func DescribeSecuritySettings(specMetadata map[string]interface{}, profile SecurityProfile) ([]string, error) {
+	settings, err := buildSecuritySettings(specMetadata, profile)
+	if err != nil {
+		return nil, err
+	}
+	return settings.report, nil
+}
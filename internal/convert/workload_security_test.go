@@ -0,0 +1,148 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// convertPodSpec returns the pod spec of the Deployment that is converted with the security profile.
+func convertPodSpec(t *testing.T, annotations map[string]interface{}, profile SecurityProfile) coreV1.PodSpec {
+	t.Helper()
+	manifests, err := ConvertWorkload(buildWorkloadTestState(t, annotations, nil, nil), "example", profile)
+	require.NoError(t, err)
+	for _, m := range manifests {
+		if d, ok := m.(*v1.Deployment); ok {
+			return d.Spec.Template.Spec
+		}
+	}
+	require.Fail(t, "no deployment")
+	return coreV1.PodSpec{}
+}
+
+func TestConvertSecurityProfile(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		podSpec := convertPodSpec(t, nil, SecurityProfileNone)
+		assert.Nil(t, podSpec.SecurityContext)
+		assert.Nil(t, podSpec.Containers[0].SecurityContext)
+	})
+
+	t.Run("baseline", func(t *testing.T) {
+		podSpec := convertPodSpec(t, nil, SecurityProfileBaseline)
+		assert.Equal(t, &coreV1.PodSecurityContext{
+			SeccompProfile: &coreV1.SeccompProfile{Type: coreV1.SeccompProfileTypeRuntimeDefault},
+		}, podSpec.SecurityContext)
+		assert.Equal(t, &coreV1.SecurityContext{
+			AllowPrivilegeEscalation: internal.Ref(false),
+		}, podSpec.Containers[0].SecurityContext)
+	})
+
+	t.Run("restricted", func(t *testing.T) {
+		podSpec := convertPodSpec(t, nil, SecurityProfileRestricted)
+		assert.Equal(t, &coreV1.PodSecurityContext{
+			RunAsNonRoot:   internal.Ref(true),
+			SeccompProfile: &coreV1.SeccompProfile{Type: coreV1.SeccompProfileTypeRuntimeDefault},
+		}, podSpec.SecurityContext)
+		assert.Equal(t, &coreV1.SecurityContext{
+			AllowPrivilegeEscalation: internal.Ref(false),
+			ReadOnlyRootFilesystem:   internal.Ref(true),
+			Capabilities:             &coreV1.Capabilities{Drop: []coreV1.Capability{"ALL"}},
+		}, podSpec.Containers[0].SecurityContext)
+	})
+
+	t.Run("relaxed restricted", func(t *testing.T) {
+		podSpec := convertPodSpec(t, map[string]interface{}{
+			internal.WorkloadRunAsNonRootAnnotation:           "false",
+			internal.WorkloadReadOnlyRootFilesystemAnnotation: "false",
+			internal.WorkloadAddCapabilitiesAnnotation:        "net_bind_service, CHOWN",
+			internal.WorkloadSeccompProfileAnnotation:         "Unconfined",
+		}, SecurityProfileRestricted)
+		assert.Equal(t, &coreV1.PodSecurityContext{
+			RunAsNonRoot:   internal.Ref(false),
+			SeccompProfile: &coreV1.SeccompProfile{Type: coreV1.SeccompProfileTypeUnconfined},
+		}, podSpec.SecurityContext)
+		assert.Equal(t, &coreV1.SecurityContext{
+			AllowPrivilegeEscalation: internal.Ref(false),
+			ReadOnlyRootFilesystem:   internal.Ref(false),
+			Capabilities: &coreV1.Capabilities{
+				Add:  []coreV1.Capability{"NET_BIND_SERVICE", "CHOWN"},
+				Drop: []coreV1.Capability{"ALL"},
+			},
+		}, podSpec.Containers[0].SecurityContext)
+	})
+
+	t.Run("annotation without profile", func(t *testing.T) {
+		podSpec := convertPodSpec(t, map[string]interface{}{
+			internal.WorkloadReadOnlyRootFilesystemAnnotation: "true",
+		}, SecurityProfileNone)
+		assert.Nil(t, podSpec.SecurityContext)
+		assert.Equal(t, &coreV1.SecurityContext{ReadOnlyRootFilesystem: internal.Ref(true)}, podSpec.Containers[0].SecurityContext)
+	})
+}
+
+func TestConvertSecurityProfile_errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annotations map[string]interface{}
+		profile     SecurityProfile
+		err         string
+	}{
+		"unknown profile": {
+			profile: "strict",
+			err:     "unknown security profile 'strict'",
+		},
+		"bad bool": {
+			annotations: map[string]interface{}{internal.WorkloadAllowPrivilegeEscalationAnnotation: "maybe"},
+			err:         "metadata: annotations: k8s.score.dev/allow-privilege-escalation: expected true or false but got 'maybe'",
+		},
+		"empty capability": {
+			annotations: map[string]interface{}{internal.WorkloadAddCapabilitiesAnnotation: "CHOWN,"},
+			err:         "metadata: annotations: k8s.score.dev/add-capabilities: expected a comma-separated list of capabilities but got 'CHOWN,'",
+		},
+		"bad seccomp profile": {
+			annotations: map[string]interface{}{internal.WorkloadSeccompProfileAnnotation: "Localhost"},
+			err:         "metadata: annotations: k8s.score.dev/seccomp-profile: expected RuntimeDefault or Unconfined but got 'Localhost'",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ConvertWorkload(buildWorkloadTestState(t, tc.annotations, nil, nil), "example", tc.profile)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestDescribeSecuritySettings(t *testing.T) {
+	settings, err := DescribeSecuritySettings(map[string]interface{}{
+		"annotations": map[string]interface{}{internal.WorkloadReadOnlyRootFilesystemAnnotation: "false"},
+	}, SecurityProfileRestricted)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"runAsNonRoot=true",
+		"allowPrivilegeEscalation=false",
+		"readOnlyRootFilesystem=false (k8s.score.dev/read-only-root-filesystem)",
+		"capabilities.drop=ALL",
+		"seccompProfile=RuntimeDefault",
+	}, settings)
+
+	settings, err = DescribeSecuritySettings(nil, SecurityProfileNone)
+	require.NoError(t, err)
+	assert.Empty(t, settings)
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/score-spec/score-go/framework"
+	coreV1 "k8s.io/api/core/v1"
+	rbacV1 "k8s.io/api/rbac/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// collectRbacRules returns the rbac rules of the resources of the workload, in the order of the resource names.
+func collectRbacRules(state *project.State, workloadName string) []rbacV1.PolicyRule {
+	var out []rbacV1.PolicyRule
+	resources := state.Workloads[workloadName].Spec.Resources
+	for _, resName := range slices.Sorted(maps.Keys(resources)) {
+		res := resources[resName]
+		out = append(out, state.Resources[framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id)].Extras.RbacRules...)
+	}
+	return out
+}
+
+// buildServiceAccount sets the ServiceAccount of the pods of the workload and returns the manifests for it. By default,
+// a ServiceAccount with the name of the workload is generated, unless the service-account-name annotation names an
+// existing one. When the resources of the workload return rbac rules, a Role with these rules is bound to the
+// ServiceAccount. The ServiceAccount token is only mounted into the pods when the workload has rbac rules, unless the
+// automount-service-account-token annotation is set. The generated manifests only carry the workload-name annotation
+// and the common labels, not the annotations of the workload.
+func buildServiceAccount(state *project.State, workloadName string, podSpec *coreV1.PodSpec, labels map[string]string) ([]machineryMeta.Object, error) {
+	specMetadata := state.Workloads[workloadName].Spec.Metadata
+	rules := collectRbacRules(state, workloadName)
+	automount, err := findBoolAnnotation(specMetadata, internal.WorkloadAutomountServiceAccountTokenAnnotation)
+	if err != nil {
+		return nil, err
+	} else if automount == nil {
+		automount = internal.Ref(len(rules) > 0)
+	}
+
+	objectMeta := func() machineryMeta.ObjectMeta {
+		return machineryMeta.ObjectMeta{
+			Name:        workloadName,
+			Annotations: map[string]string{internal.AnnotationPrefix + "workload-name": workloadName},
+			Labels:      maps.Clone(labels),
+		}
+	}
+
+	var out []machineryMeta.Object
+	serviceAccountName, ok := internal.FindAnnotation(specMetadata, internal.WorkloadServiceAccountNameAnnotation)
+	if !ok {
+		serviceAccountName = workloadName
+		out = append(out, &coreV1.ServiceAccount{
+			TypeMeta:                     machineryMeta.TypeMeta{Kind: "ServiceAccount", APIVersion: "v1"},
+			ObjectMeta:                   objectMeta(),
+			AutomountServiceAccountToken: automount,
+		})
+	}
+	podSpec.ServiceAccountName = serviceAccountName
+	podSpec.AutomountServiceAccountToken = automount
+
+	if len(rules) > 0 {
+		out = append(out, &rbacV1.Role{
+			TypeMeta:   machineryMeta.TypeMeta{Kind: "Role", APIVersion: "rbac.authorization.k8s.io/v1"},
+			ObjectMeta: objectMeta(),
+			Rules:      rules,
+		}, &rbacV1.RoleBinding{
+			TypeMeta:   machineryMeta.TypeMeta{Kind: "RoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+			ObjectMeta: objectMeta(),
+			Subjects:   []rbacV1.Subject{{Kind: rbacV1.ServiceAccountKind, Name: serviceAccountName}},
+			RoleRef: rbacV1.RoleRef{
+				APIGroup: rbacV1.GroupName,
+				Kind:     "Role",
+				Name:     workloadName,
+			},
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacV1 "k8s.io/api/rbac/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func TestConvertServiceAccount(t *testing.T) {
+	// withRbacRules returns a state where the resources of the workload return rbac rules.
+	withRbacRules := func(t *testing.T, annotations map[string]interface{}) *project.State {
+		state := buildWorkloadTestState(t, annotations, nil, map[string]scoretypes.Resource{
+			"flags":  {Type: "feature-flags"},
+			"config": {Type: "config"},
+		})
+		for uid, rule := range map[framework.ResourceUid]rbacV1.PolicyRule{
+			framework.NewResourceUid("example", "flags", "feature-flags", nil, nil): {
+				APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"flags"}, Verbs: []string{"get", "watch"},
+			},
+			framework.NewResourceUid("example", "config", "config", nil, nil): {
+				APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"config"}, Verbs: []string{"get"},
+			},
+		} {
+			res := state.Resources[uid]
+			res.Extras.RbacRules = []rbacV1.PolicyRule{rule}
+			state.Resources[uid] = res
+		}
+		return state
+	}
+
+	t.Run("without rbac rules", func(t *testing.T) {
+		out, err := encodeManifests(t, buildWorkloadTestState(t, nil, nil, nil))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out, `apiVersion: v1
+automountServiceAccountToken: false
+kind: ServiceAccount
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+---
+`), out)
+		assert.NotContains(t, out, "kind: Role")
+		podSpec := convertPodSpec(t, nil, SecurityProfileNone)
+		assert.Equal(t, "example", podSpec.ServiceAccountName)
+		assert.Equal(t, internal.Ref(false), podSpec.AutomountServiceAccountToken)
+	})
+
+	t.Run("with rbac rules", func(t *testing.T) {
+		out, err := encodeManifests(t, withRbacRules(t, nil))
+		require.NoError(t, err)
+		assert.Contains(t, out, `apiVersion: v1
+automountServiceAccountToken: true
+kind: ServiceAccount
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+rules:
+- apiGroups:
+  - ""
+  resourceNames:
+  - config
+  resources:
+  - secrets
+  verbs:
+  - get
+- apiGroups:
+  - ""
+  resourceNames:
+  - flags
+  resources:
+  - configmaps
+  verbs:
+  - get
+  - watch
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: example
+subjects:
+- kind: ServiceAccount
+  name: example
+---
+`)
+		assert.Contains(t, out, "      automountServiceAccountToken: true\n")
+		assert.Contains(t, out, "      serviceAccountName: example\n")
+	})
+
+	t.Run("with existing service account", func(t *testing.T) {
+		out, err := encodeManifests(t, withRbacRules(t, map[string]interface{}{
+			internal.WorkloadServiceAccountNameAnnotation:           "shared",
+			internal.WorkloadAutomountServiceAccountTokenAnnotation: "false",
+		}))
+		require.NoError(t, err)
+		assert.NotContains(t, out, "\nkind: ServiceAccount\n")
+		assert.Contains(t, out, `subjects:
+- kind: ServiceAccount
+  name: shared
+`)
+		assert.Contains(t, out, "      automountServiceAccountToken: false\n")
+		assert.Contains(t, out, "      serviceAccountName: shared\n")
+	})
+
+	t.Run("workload annotations are only copied to the pods", func(t *testing.T) {
+		out, err := encodeManifests(t, withRbacRules(t, map[string]interface{}{
+			"custom.annotation/key": "something",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(out, "custom.annotation/key: something"), out)
+		assert.Equal(t, 1, strings.Count(out, "\n        custom.annotation/key: something\n"), out)
+	})
+
+	t.Run("invalid automount", func(t *testing.T) {
+		_, err := encodeManifests(t, buildWorkloadTestState(t, map[string]interface{}{
+			internal.WorkloadAutomountServiceAccountTokenAnnotation: "yes",
+		}, nil, nil))
+		assert.EqualError(t, err, "metadata: annotations: k8s.score.dev/automount-service-account-token: expected true or false but got 'yes'")
+	})
+}
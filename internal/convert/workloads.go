@@ -18,12 +18,14 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/score-spec/score-go/framework"
 	scoretypes "github.com/score-spec/score-go/types"
 	v1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
 	coreV1 "k8s.io/api/core/v1"
 	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -35,13 +37,18 @@ import (
 const (
 	WorkloadKindDeployment  = "Deployment"
 	WorkloadKindStatefulSet = "StatefulSet"
+	WorkloadKindJob         = "Job"
+	WorkloadKindCronJob     = "CronJob"
+	WorkloadKindDaemonSet   = "DaemonSet"
 
 	SelectorLabelName      = "app.kubernetes.io/name"
 	SelectorLabelInstance  = "app.kubernetes.io/instance"
 	SelectorLabelManagedBy = "app.kubernetes.io/managed-by"
 )
 
-func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta.Object, error) {
+var supportedWorkloadKinds = []string{WorkloadKindDeployment, WorkloadKindStatefulSet, WorkloadKindJob, WorkloadKindCronJob, WorkloadKindDaemonSet}
+
+func ConvertWorkload(state *project.State, workloadName string, securityProfile SecurityProfile) ([]machineryMeta.Object, error) {
 	resOutputs, err := state.GetResourceOutputForWorkload(workloadName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to generate outputs")
@@ -54,10 +61,13 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 	kind := WorkloadKindDeployment
 	if d, ok := internal.FindAnnotation(spec.Metadata, internal.WorkloadKindAnnotation); ok {
 		kind = d
-		if kind != WorkloadKindDeployment && kind != WorkloadKindStatefulSet {
-			return nil, errors.Wrapf(err, "metadata: annotations: %s: unsupported workload kind", internal.WorkloadKindAnnotation)
+		if !slices.Contains(supportedWorkloadKinds, kind) {
+			return nil, errors.Errorf("metadata: annotations: %s: unsupported workload kind '%s'", internal.WorkloadKindAnnotation, kind)
 		}
 	}
+	if err := checkScalingAnnotations(spec.Metadata, kind); err != nil {
+		return nil, err
+	}
 
 	// containers and volumes here are fun..
 	// we have to collect them all based on the parent paths they get mounted in and turn these into projected volumes
@@ -119,7 +129,7 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 				containerVolumeMounts = append(containerVolumeMounts, mount)
 				if claim != nil {
 					if kind != WorkloadKindStatefulSet {
-						return nil, errors.Wrapf(err, "containers.%s.volumes.%s: volume claims can only be set on stateful sets", containerName, target)
+						return nil, errors.Errorf("containers.%s.volumes.%s: volume claims can only be set on stateful sets", containerName, target)
 					}
 					volumeClaimTemplates = append(volumeClaimTemplates, *claim)
 				} else if vol != nil {
@@ -173,8 +183,12 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 	}
 
 	if spec.Service != nil && len(spec.Service.Ports) > 0 {
+		if kind == WorkloadKindJob || kind == WorkloadKindCronJob {
+			return nil, errors.Errorf("service: ports cannot be exposed by workload kind %s", kind)
+		}
 		portList := make([]coreV1.ServicePort, 0, len(spec.Service.Ports))
-		for portName, port := range spec.Service.Ports {
+		for _, portName := range slices.Sorted(maps.Keys(spec.Service.Ports)) {
+			port := spec.Service.Ports[portName]
 			var proto = coreV1.ProtocolTCP
 			if port.Protocol != nil && *port.Protocol != "" {
 				proto = coreV1.Protocol(strings.ToUpper(string(*port.Protocol)))
@@ -206,6 +220,53 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 		})
 	}
 
+	podTemplate := coreV1.PodTemplateSpec{
+		ObjectMeta: machineryMeta.ObjectMeta{
+			Labels:      commonLabels,
+			Annotations: podAnnotations,
+		},
+		Spec: coreV1.PodSpec{
+			Containers: containers,
+			Volumes:    volumes,
+		},
+	}
+	if podTemplate.Spec.NodeSelector, err = buildNodeSelector(spec.Metadata); err != nil {
+		return nil, err
+	}
+	if podTemplate.Spec.Tolerations, err = buildTolerations(spec.Metadata); err != nil {
+		return nil, err
+	}
+	security, err := buildSecuritySettings(spec.Metadata, securityProfile)
+	if err != nil {
+		return nil, err
+	}
+	security.apply(&podTemplate.Spec)
+	serviceAccountManifests, err := buildServiceAccount(state, workloadName, &podTemplate.Spec, commonLabels)
+	if err != nil {
+		return nil, err
+	}
+	manifests = append(manifests, serviceAccountManifests...)
+	replicas, err := buildReplicas(spec.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	hpa, err := buildHorizontalPodAutoscaler(spec.Metadata, kind, machineryMeta.ObjectMeta{
+		Name:        workloadName,
+		Annotations: topLevelAnnotations,
+		Labels:      commonLabels,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pdb, err := buildPodDisruptionBudget(spec.Metadata, kind, state.Extras.DefaultDisruptionBudget, replicas, hpa, machineryMeta.ObjectMeta{
+		Name:        workloadName,
+		Annotations: topLevelAnnotations,
+		Labels:      commonLabels,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	switch kind {
 	case WorkloadKindDeployment:
 		manifests = append(manifests, &v1.Deployment{
@@ -216,21 +277,13 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 				Labels:      commonLabels,
 			},
 			Spec: v1.DeploymentSpec{
+				Replicas: replicas,
 				Selector: &machineryMeta.LabelSelector{
 					MatchLabels: map[string]string{
 						SelectorLabelInstance: commonLabels[SelectorLabelInstance],
 					},
 				},
-				Template: coreV1.PodTemplateSpec{
-					ObjectMeta: machineryMeta.ObjectMeta{
-						Labels:      commonLabels,
-						Annotations: podAnnotations,
-					},
-					Spec: coreV1.PodSpec{
-						Containers: containers,
-						Volumes:    volumes,
-					},
-				},
+				Template: podTemplate,
 			},
 		})
 	case WorkloadKindStatefulSet:
@@ -261,26 +314,74 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 				Labels:      commonLabels,
 			},
 			Spec: v1.StatefulSetSpec{
+				Replicas: replicas,
 				Selector: &machineryMeta.LabelSelector{
 					MatchLabels: map[string]string{
 						SelectorLabelInstance: commonLabels[SelectorLabelInstance],
 					},
 				},
 				ServiceName: headlessServiceName,
-				Template: coreV1.PodTemplateSpec{
-					ObjectMeta: machineryMeta.ObjectMeta{
-						Labels:      commonLabels,
-						Annotations: podAnnotations,
-					},
-					Spec: coreV1.PodSpec{
-						Containers: containers,
-						Volumes:    volumes,
-					},
-				},
+				Template:    podTemplate,
 				// So the puzzle here is how to get this from our volumes...
 				VolumeClaimTemplates: volumeClaimTemplates,
 			},
 		})
+	case WorkloadKindDaemonSet:
+		updateStrategy, err := buildDaemonSetUpdateStrategy(spec.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, &v1.DaemonSet{
+			TypeMeta: machineryMeta.TypeMeta{Kind: WorkloadKindDaemonSet, APIVersion: "apps/v1"},
+			ObjectMeta: machineryMeta.ObjectMeta{
+				Name:        workloadName,
+				Annotations: topLevelAnnotations,
+				Labels:      commonLabels,
+			},
+			Spec: v1.DaemonSetSpec{
+				Selector: &machineryMeta.LabelSelector{
+					MatchLabels: map[string]string{
+						SelectorLabelInstance: commonLabels[SelectorLabelInstance],
+					},
+				},
+				Template:       podTemplate,
+				UpdateStrategy: updateStrategy,
+			},
+		})
+	case WorkloadKindJob:
+		jobSpec, err := buildJobSpec(spec.Metadata, podTemplate)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, &batchV1.Job{
+			TypeMeta: machineryMeta.TypeMeta{Kind: WorkloadKindJob, APIVersion: "batch/v1"},
+			ObjectMeta: machineryMeta.ObjectMeta{
+				Name:        workloadName,
+				Annotations: topLevelAnnotations,
+				Labels:      commonLabels,
+			},
+			Spec: *jobSpec,
+		})
+	case WorkloadKindCronJob:
+		cronJobSpec, err := buildCronJobSpec(spec.Metadata, commonLabels, podTemplate)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, &batchV1.CronJob{
+			TypeMeta: machineryMeta.TypeMeta{Kind: WorkloadKindCronJob, APIVersion: "batch/v1"},
+			ObjectMeta: machineryMeta.ObjectMeta{
+				Name:        workloadName,
+				Annotations: topLevelAnnotations,
+				Labels:      commonLabels,
+			},
+			Spec: *cronJobSpec,
+		})
+	}
+	if hpa != nil {
+		manifests = append(manifests, hpa)
+	}
+	if pdb != nil {
+		manifests = append(manifests, pdb)
 	}
 
 	return manifests, nil
@@ -293,6 +394,18 @@ func WorkloadServiceName(workloadName string, specMetadata map[string]interface{
 	return workloadName
 }
 
+// findInt32Annotation parses the annotation as a non-negative 32-bit integer. It returns nil if the annotation is not set.
+func findInt32Annotation(specMetadata map[string]interface{}, annotation string) (*int32, error) {
+	if d, ok := internal.FindAnnotation(specMetadata, annotation); ok {
+		v, err := strconv.ParseInt(d, 10, 32)
+		if err != nil || v < 0 {
+			return nil, errors.Errorf("metadata: annotations: %s: expected a non-negative integer but got '%s'", annotation, d)
+		}
+		return internal.Ref(int32(v)), nil
+	}
+	return nil, nil
+}
+
 func buildProbe(probe *scoretypes.ContainerProbe) (*coreV1.Probe, error) {
 	if input := probe.HttpGet; input != nil {
 		ph := coreV1.ProbeHandler{
@@ -29,6 +29,42 @@ import (
 	"github.com/score-spec/score-k8s/internal/project"
 )
 
+// buildWorkloadTestState returns a state with an "example" workload that has the annotations, service, and resources,
+// with its resources primed.
+func buildWorkloadTestState(t *testing.T, annotations map[string]interface{}, service *scoretypes.WorkloadService, resources map[string]scoretypes.Resource) *project.State {
+	t.Helper()
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name":        "example",
+			"annotations": annotations,
+		},
+		Containers: map[string]scoretypes.Container{
+			"main": {Image: "busybox", Command: []string{"echo", "hello"}},
+		},
+		Service:   service,
+		Resources: resources,
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abcdef"})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+	return state
+}
+
+// encodeManifests converts the "example" workload and encodes the manifests as a multi-document yaml.
+func encodeManifests(t *testing.T, state *project.State) (string, error) {
+	t.Helper()
+	manifests, err := ConvertWorkload(state, "example", SecurityProfileNone)
+	if err != nil {
+		return "", err
+	}
+	out := new(bytes.Buffer)
+	for _, manifest := range manifests {
+		require.NoError(t, internal.YamlSerializerInfo.Serializer.Encode(manifest.(runtime.Object), out))
+		out.WriteString("---\n")
+	}
+	return out.String(), nil
+}
+
 func TestMassive(t *testing.T) {
 	var err error
 	state := new(project.State)
@@ -124,7 +160,7 @@ func TestMassive(t *testing.T) {
 			},
 		},
 	}
-	manifests, err := ConvertWorkload(state, "example")
+	manifests, err := ConvertWorkload(state, "example", SecurityProfileNone)
 	require.NoError(t, err)
 	out := new(bytes.Buffer)
 	for _, manifest := range manifests {
@@ -168,6 +204,18 @@ spec:
 status:
   loadBalancer: {}
 ---
+apiVersion: v1
+automountServiceAccountToken: false
+kind: ServiceAccount
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abcdef
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+---
 apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -193,6 +241,7 @@ spec:
         app.kubernetes.io/managed-by: score-k8s
         app.kubernetes.io/name: example
     spec:
+      automountServiceAccountToken: false
       containers:
       - args:
         - with
@@ -236,6 +285,7 @@ spec:
       - image: other-image
         name: c2
         resources: {}
+      serviceAccountName: example
       volumes:
       - emptyDir: {}
         name: vol-5e3859fe72
@@ -18,6 +18,8 @@ import (
 	appsV1 "k8s.io/api/apps/v1"
 	appsV1b1 "k8s.io/api/apps/v1beta1"
 	appsV1b2 "k8s.io/api/apps/v1beta2"
+	autoscalingV2 "k8s.io/api/autoscaling/v2"
+	batchV1 "k8s.io/api/batch/v1"
 	coreV1 "k8s.io/api/core/v1"
 	networkingV1 "k8s.io/api/networking/v1"
 	networkingV1b1 "k8s.io/api/networking/v1beta1"
@@ -34,6 +36,8 @@ func init() {
 	_ = appsV1.AddToScheme(scheme)
 	_ = appsV1b1.AddToScheme(scheme)
 	_ = appsV1b2.AddToScheme(scheme)
+	_ = autoscalingV2.AddToScheme(scheme)
+	_ = batchV1.AddToScheme(scheme)
 	_ = networkingV1.AddToScheme(scheme)
 	_ = networkingV1b1.AddToScheme(scheme)
 	K8sCodecFactory = serializer.NewCodecFactory(scheme)
@@ -0,0 +1,86 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LockFileName = "lock.yaml"
+)
+
+// The LockFile records the content of the provisioners and patch templates that were fetched by init so that the
+// content fetched by later runs can be checked against it.
+type LockFile struct {
+	Provisioners   []LockedFile `yaml:"provisioners,omitempty"`
+	PatchTemplates []LockedFile `yaml:"patch_templates,omitempty"`
+}
+
+// LockedFile is a single file fetched from a source uri.
+type LockedFile struct {
+	// Source is the uri that was passed to init.
+	Source string `yaml:"source"`
+	// Uri is the uri or path of the file, this differs from the source when the source is a directory.
+	Uri string `yaml:"uri"`
+	// Revision is the resolved git commit or OCI manifest digest of the source, if any.
+	Revision string `yaml:"revision,omitempty"`
+	// Sha256 is the hex encoded SHA-256 checksum of the content of the file.
+	Sha256 string `yaml:"sha256"`
+}
+
+// Persist writes the lock file into the state directory.
+func (l *LockFile) Persist(stateDirectory string) error {
+	out := new(bytes.Buffer)
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return fmt.Errorf("failed to encode content: %w", err)
+	}
+
+	// overwrite this file atomically in the same way as the state file
+	if err := os.WriteFile(filepath.Join(stateDirectory, LockFileName+".temp"), out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write lock file: %w", err)
+	} else if err := os.Rename(filepath.Join(stateDirectory, LockFileName+".temp"), filepath.Join(stateDirectory, LockFileName)); err != nil {
+		return fmt.Errorf("failed to complete writing lock file: %w", err)
+	}
+	return nil
+}
+
+// LoadLockFile loads the lock file from the state directory. An empty lock file is returned when it does not exist.
+func LoadLockFile(stateDirectory string) (*LockFile, bool, error) {
+	content, err := os.ReadFile(filepath.Join(stateDirectory, LockFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return new(LockFile), false, nil
+		}
+		return nil, false, fmt.Errorf("lock file couldn't be read: %w", err)
+	}
+
+	out := new(LockFile)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return nil, true, fmt.Errorf("lock file couldn't be decoded: %w", err)
+	}
+	return out, true, nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/score-spec/score-go/framework"
 	"gopkg.in/yaml.v3"
+	rbacV1 "k8s.io/api/rbac/v1"
 )
 
 const (
@@ -32,6 +33,14 @@ const (
 
 type StateExtras struct {
 	PatchingTemplates []string `yaml:"patching_templates"`
+	// DefaultDisruptionBudget is applied to each workload with more than one replica that does not set its own.
+	DefaultDisruptionBudget *DisruptionBudget `yaml:"default_disruption_budget,omitempty"`
+}
+
+// DisruptionBudget is the minAvailable or maxUnavailable of a PodDisruptionBudget, as a number or percentage of pods.
+type DisruptionBudget struct {
+	MinAvailable   string `yaml:"min_available,omitempty"`
+	MaxUnavailable string `yaml:"max_unavailable,omitempty"`
 }
 
 type WorkloadExtras struct {
@@ -41,6 +50,11 @@ type WorkloadExtras struct {
 type ResourceExtras struct {
 	// Don't actually persist these manifests, we just hold them here so we can pass them around.
 	Manifests []map[string]interface{} `yaml:"-"`
+	// SharedStateKeys are the top level shared state keys set by the last provisioning of this resource. These are
+	// used to clean up the shared state when the resource is removed.
+	SharedStateKeys []string `yaml:"shared_state_keys,omitempty"`
+	// RbacRules are not persisted either, they are granted to the ServiceAccount of each workload using the resource.
+	RbacRules []rbacV1.PolicyRule `yaml:"-"`
 }
 
 type State = framework.State[StateExtras, WorkloadExtras, ResourceExtras]
@@ -18,14 +18,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/score-spec/score-go/framework"
 	"gopkg.in/yaml.v3"
@@ -33,6 +37,24 @@ import (
 	"github.com/score-spec/score-k8s/internal/provisioners"
 )
 
+const (
+	// maxErrorOutputLength is the number of bytes from the end of stdout and stderr that are included in errors.
+	maxErrorOutputLength = 1024
+	// waitDelay is how long to wait for the output of a killed command to be closed before giving up on it.
+	waitDelay = time.Second
+)
+
+// retryBackoff is the delay before the first retry, it doubles for each subsequent retry.
+var retryBackoff = time.Second
+
+// Env controls the environment variables of the command.
+type Env struct {
+	// Allow is the list of environment variables that are passed through to the command when they are set.
+	Allow []string `yaml:"allow,omitempty"`
+	// Set adds environment variables to the command. Values may reference environment variables like ${NAME}.
+	Set map[string]string `yaml:"set,omitempty"`
+}
+
 type Provisioner struct {
 	ProvisionerUri string   `yaml:"uri"`
 	ResType        string   `yaml:"type"`
@@ -48,6 +70,24 @@ type Provisioner struct {
 
 	// ExpectedOutputs is a list of expected outputs that the provisioner should return.
 	ExpectedOutputs []string `yaml:"expected_outputs,omitempty"`
+
+	// CommonFields holds the params and outputs schemas, strict, match, and priority fields.
+	provisioners.CommonFields `yaml:",inline"`
+
+	// ResReadsSharedState can be set to false when the provisioner does not read the shared state, so that the resource
+	// can be provisioned in parallel with the resources before it.
+	ResReadsSharedState *bool `yaml:"reads_shared_state,omitempty"`
+
+	// Timeout is the duration, like 30s, after which each execution of the command is killed.
+	Timeout string `yaml:"timeout,omitempty"`
+	// Env restricts the environment of the command. When it is not set, the command inherits the whole environment.
+	Env *Env `yaml:"env,omitempty"`
+	// Workdir is the working directory of the command, it defaults to the current working directory.
+	Workdir string `yaml:"workdir,omitempty"`
+	// Retries is the number of times the command is executed again after it fails or times out.
+	Retries int `yaml:"retries,omitempty"`
+
+	timeout time.Duration
 }
 
 func (p *Provisioner) Description() string {
@@ -89,6 +129,10 @@ func (p *Provisioner) Outputs() []string {
 	return outputs
 }
 
+func (p *Provisioner) ReadsSharedState() bool {
+	return p.ResReadsSharedState == nil || *p.ResReadsSharedState
+}
+
 func (p *Provisioner) Match(resUid framework.ResourceUid) bool {
 	if resUid.Type() != p.ResType {
 		return false
@@ -137,44 +181,152 @@ func decodeBinary(uri string) (string, error) {
 	return filepath.Join(pathParts...), nil
 }
 
+// decodeOutput decodes the json output that the command printed to stdout.
+func decodeOutput(outputBuffer *bytes.Buffer) (*provisioners.ProvisionOutput, error) {
+	var output provisioners.ProvisionOutput
+	dec := json.NewDecoder(bytes.NewReader(outputBuffer.Bytes()))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&output); err != nil {
+		slog.Debug("Output from command provisioner:\n" + outputBuffer.String())
+		return nil, fmt.Errorf("failed to decode output from cmd provisioner: %w", err)
+	}
+	return &output, nil
+}
+
 func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
-	bin, err := decodeBinary(p.Uri())
+	outputBuffer, err := p.execute(ctx, "provision", input)
 	if err != nil {
 		return nil, err
 	}
+	return decodeOutput(outputBuffer)
+}
 
-	rawInput, err := json.Marshal(input)
+// Deprovision executes the command with the <mode> arg set to "deprovision" and the last state of the resource. The
+// command may print an output with shared state modifications or nothing at all. Commands without a <mode> arg cannot
+// tell the difference between provisioning and deprovisioning, so they are not executed.
+func (p *Provisioner) Deprovision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	if !slices.Contains(p.Args, "<mode>") {
+		slog.Debug(fmt.Sprintf("Skipping deprovision for cmd provisioner '%s' without a <mode> arg", p.Uri()))
+		return nil, nil
+	}
+	outputBuffer, err := p.execute(ctx, "deprovision", input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode json input: %w", err)
+		return nil, err
+	}
+	if strings.TrimSpace(outputBuffer.String()) == "" {
+		return nil, nil
 	}
-	outputBuffer := new(bytes.Buffer)
+	return decodeOutput(outputBuffer)
+}
 
-	// if there is a <mode> arg, we mark it as "provision".
-	args := slices.Clone(p.Args)
-	for i, arg := range args {
-		if arg == "<mode>" {
-			args[i] = "provision"
+// buildEnv returns the allowed environment variables along with the added ones. A nil environment means that the
+// command inherits the whole environment.
+func (p *Provisioner) buildEnv() ([]string, error) {
+	if p.Env == nil {
+		return nil, nil
+	}
+	out := make([]string, 0, len(p.Env.Allow)+len(p.Env.Set))
+	for _, name := range p.Env.Allow {
+		if v, ok := os.LookupEnv(name); ok {
+			out = append(out, name+"="+v)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(p.Env.Set)) {
+		var missing []string
+		value := os.Expand(p.Env.Set[name], func(k string) string {
+			v, ok := os.LookupEnv(k)
+			if !ok {
+				missing = append(missing, k)
+			}
+			return v
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("env '%s' references unset environment variable '%s'", name, missing[0])
 		}
+		out = append(out, name+"="+value)
 	}
+	return out, nil
+}
+
+// outputTail returns the end of the output of a failed command for including in the error.
+func outputTail(name string, buffer *bytes.Buffer) string {
+	raw := bytes.TrimSpace(buffer.Bytes())
+	if len(raw) == 0 {
+		return ""
+	} else if len(raw) > maxErrorOutputLength {
+		raw = append([]byte("..."), raw[len(raw)-maxErrorOutputLength:]...)
+	}
+	return fmt.Sprintf("\n%s: %s", name, raw)
+}
 
+// run executes the command once, killing it when the timeout expires, and returns stdout.
+func (p *Provisioner) run(ctx context.Context, bin string, args []string, env []string, rawInput []byte) (*bytes.Buffer, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
 	cmd := exec.CommandContext(ctx, bin, args...)
-	slog.Debug(fmt.Sprintf("Executing '%s %v' for command provisioner", bin, args))
 	cmd.Stdin = bytes.NewReader(rawInput)
-	cmd.Stdout = outputBuffer
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdout
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+	cmd.Env = env
+	cmd.Dir = p.Workdir
+	cmd.WaitDelay = waitDelay
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("failed to execute cmd provisioner: %w", err)
+		if p.timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", p.timeout, err)
+		}
+		return nil, fmt.Errorf("%w%s%s", err, outputTail("stdout", stdout), outputTail("stderr", stderr))
 	}
+	return stdout, nil
+}
 
-	var output provisioners.ProvisionOutput
-	dec := json.NewDecoder(bytes.NewReader(outputBuffer.Bytes()))
-	dec.DisallowUnknownFields()
-	if err := dec.Decode(&output); err != nil {
-		slog.Debug("Output from command provisioner:\n" + outputBuffer.String())
-		return nil, fmt.Errorf("failed to decode output from cmd provisioner: %w", err)
+// execute runs the command with the json input on stdin and any <mode> arg replaced by the mode, and returns stdout.
+func (p *Provisioner) execute(ctx context.Context, mode string, input *provisioners.Input) (*bytes.Buffer, error) {
+	bin, err := decodeBinary(p.Uri())
+	if err != nil {
+		return nil, err
 	}
 
-	return &output, nil
+	rawInput, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json input: %w", err)
+	}
+	env, err := p.buildEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	// if there is a <mode> arg, we replace it with the mode.
+	args := slices.Clone(p.Args)
+	for i, arg := range args {
+		if arg == "<mode>" {
+			args[i] = mode
+		}
+	}
+
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		slog.Debug(fmt.Sprintf("Executing '%s %v' for command provisioner", bin, args))
+		outputBuffer, err := p.run(ctx, bin, args, env, rawInput)
+		if err == nil {
+			return outputBuffer, nil
+		} else if attempt >= p.Retries {
+			if attempt == 0 {
+				return nil, fmt.Errorf("failed to execute cmd provisioner: %w", err)
+			}
+			return nil, fmt.Errorf("failed to execute cmd provisioner after %d attempts: %w", attempt+1, err)
+		}
+		slog.Warn(fmt.Sprintf("Cmd provisioner '%s' failed, retrying in %s: %v", p.ProvisionerUri, backoff, err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to execute cmd provisioner after %d attempts: %w", attempt+1, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
 
 func Parse(raw map[string]interface{}) (*Provisioner, error) {
@@ -190,6 +342,9 @@ func Parse(raw map[string]interface{}) (*Provisioner, error) {
 	} else if p.ResType == "" {
 		return nil, fmt.Errorf("type not set")
 	}
+	if err := p.CommonFields.Validate(); err != nil {
+		return nil, err
+	}
 
 	parts, err := url.Parse(p.ProvisionerUri)
 	if err != nil {
@@ -202,5 +357,29 @@ func Parse(raw map[string]interface{}) (*Provisioner, error) {
 		return nil, fmt.Errorf("cmd provisioner uri cannot contain a port")
 	}
 
+	if p.Timeout != "" {
+		if p.timeout, err = time.ParseDuration(p.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		} else if p.timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout: must be greater than 0")
+		}
+	}
+	if p.Retries < 0 {
+		return nil, fmt.Errorf("invalid retries: must not be negative")
+	}
+	if p.Env != nil {
+		for _, name := range slices.Concat(p.Env.Allow, slices.Collect(maps.Keys(p.Env.Set))) {
+			if name == "" || strings.Contains(name, "=") {
+				return nil, fmt.Errorf("invalid env name '%s'", name)
+			}
+		}
+	}
+
 	return p, nil
 }
+
+var _ provisioners.Provisioner = (*Provisioner)(nil)
+var _ provisioners.SchemaProvisioner = (*Provisioner)(nil)
+var _ provisioners.Deprovisioner = (*Provisioner)(nil)
+var _ provisioners.ConditionalProvisioner = (*Provisioner)(nil)
+var _ provisioners.SharedStateProvisioner = (*Provisioner)(nil)
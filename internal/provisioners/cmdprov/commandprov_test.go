@@ -20,6 +20,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,6 +28,26 @@ import (
 	"github.com/score-spec/score-k8s/internal/provisioners"
 )
 
+func init() {
+	retryBackoff = time.Millisecond
+}
+
+func TestParse_fail(t *testing.T) {
+	for k, v := range map[string]map[string]interface{}{
+		"invalid timeout: time: invalid duration \"bananas\"": {"timeout": "bananas"},
+		"invalid timeout: must be greater than 0":             {"timeout": "0s"},
+		"invalid retries: must not be negative":               {"retries": -1},
+		"invalid env name 'A=B'":                              {"env": map[string]interface{}{"allow": []string{"A=B"}}},
+	} {
+		t.Run(k, func(t *testing.T) {
+			v["uri"] = "cmd://sh"
+			v["type"] = "thing"
+			_, err := Parse(v)
+			assert.EqualError(t, err, k)
+		})
+	}
+}
+
 func TestParseUri_success(t *testing.T) {
 	for _, k := range []string{
 		"cmd://python",
@@ -129,3 +150,110 @@ func TestProvision_fail_decode(t *testing.T) {
 	})
 	require.EqualError(t, err, "failed to decode output from cmd provisioner: invalid character 'b' looking for beginning of value")
 }
+
+func TestDeprovision_success(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "cmd://sh",
+		"type": "thing",
+		"args": []string{"-c", `test "$1" = deprovision && echo '{"shared_state":{"last":' "$(cat)" '}}'`, "--", "<mode>"},
+	})
+	require.NoError(t, err)
+	po, err := p.Deprovision(context.Background(), &provisioners.Input{
+		ResourceUid:   "thing.default#w.r",
+		ResourceState: map[string]interface{}{"key": "value"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"key": "value"}, po.SharedState["last"].(map[string]interface{})["resource_state"])
+}
+
+func TestDeprovision_no_output(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "cmd://sh",
+		"type": "thing",
+		"args": []string{"-c", `test "$1" = deprovision`, "--", "<mode>"},
+	})
+	require.NoError(t, err)
+	po, err := p.Deprovision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.NoError(t, err)
+	assert.Nil(t, po)
+}
+
+func TestDeprovision_skipped_without_mode(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "cmd://sh",
+		"type": "thing",
+		"args": []string{"-c", "false"},
+	})
+	require.NoError(t, err)
+	po, err := p.Deprovision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.NoError(t, err)
+	assert.Nil(t, po)
+}
+
+func TestDeprovision_fail_command(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "cmd://sh",
+		"type": "thing",
+		"args": []string{"-c", "false", "<mode>"},
+	})
+	require.NoError(t, err)
+	_, err = p.Deprovision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.EqualError(t, err, "failed to execute cmd provisioner: exit status 1")
+}
+
+func TestProvision_timeout(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":     "cmd://sh",
+		"type":    "thing",
+		"args":    []string{"-c", "echo partial; echo waiting >&2; exec sleep 10"},
+		"timeout": "200ms",
+	})
+	require.NoError(t, err)
+	_, err = p.Provision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.EqualError(t, err, "failed to execute cmd provisioner: timed out after 200ms: signal: killed\nstdout: partial\nstderr: waiting")
+}
+
+func TestProvision_env(t *testing.T) {
+	t.Setenv("SCORE_K8S_TEST_ALLOWED", "a")
+	t.Setenv("SCORE_K8S_TEST_DENIED", "b")
+	p, err := Parse(map[string]interface{}{
+		"uri":  "cmd://sh",
+		"type": "thing",
+		"args": []string{"-c", `echo "{\"resource_outputs\":{\"allowed\":\"$SCORE_K8S_TEST_ALLOWED\",\"denied\":\"$SCORE_K8S_TEST_DENIED\",\"set\":\"$SET\"}}"`},
+		"env": map[string]interface{}{
+			"allow": []string{"SCORE_K8S_TEST_ALLOWED"},
+			"set":   map[string]interface{}{"SET": "${SCORE_K8S_TEST_DENIED}-c"},
+		},
+	})
+	require.NoError(t, err)
+	po, err := p.Provision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"allowed": "a", "denied": "", "set": "b-c"}, po.ResourceOutputs)
+
+	p.Env.Set["SET"] = "${SCORE_K8S_TEST_UNSET}"
+	_, err = p.Provision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.EqualError(t, err, "env 'SET' references unset environment variable 'SCORE_K8S_TEST_UNSET'")
+}
+
+func TestProvision_workdir_and_retries(t *testing.T) {
+	td := t.TempDir()
+	newProvisioner := func(retries int) *Provisioner {
+		p, err := Parse(map[string]interface{}{
+			"uri":     "cmd://sh",
+			"type":    "thing",
+			"args":    []string{"-c", `n=$(cat count 2>/dev/null || echo 0); echo $((n+1)) > count; test $n -ge 2 && echo "{\"resource_outputs\":{\"pwd\":\"$(pwd)\"}}"`},
+			"workdir": td,
+			"retries": retries,
+		})
+		require.NoError(t, err)
+		return p
+	}
+
+	_, err := newProvisioner(1).Provision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.EqualError(t, err, "failed to execute cmd provisioner after 2 attempts: exit status 1")
+
+	require.NoError(t, os.Remove(filepath.Join(td, "count")))
+	po, err := newProvisioner(2).Provision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"pwd": td}, po.ResourceOutputs)
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// CommonFields are the fields that all the provisioner definitions support to check the resource params and outputs
+// and to choose between provisioners. It is embedded inline in each provisioner definition and implements
+// SchemaProvisioner, StrictProvisioner, and ConditionalProvisioner for them.
+type CommonFields struct {
+	// ResParamsSchema is an optional JSON schema that the resource params are validated against after the defaults in
+	// it have been applied.
+	ResParamsSchema map[string]interface{} `yaml:"params_schema,omitempty"`
+
+	// ResOutputsSchema is an optional JSON schema that the resource outputs are validated against.
+	ResOutputsSchema map[string]interface{} `yaml:"outputs_schema,omitempty"`
+
+	// ResStrict can be set to false to skip checking the supported params and expected outputs.
+	ResStrict *bool `yaml:"strict,omitempty"`
+
+	// ResMatch are optional conditions that a resource must also meet for the provisioner to match it.
+	ResMatch *MatchConditions `yaml:"match,omitempty"`
+
+	// ResPriority orders the provisioners that match the same resource, the highest priority takes precedence.
+	ResPriority int `yaml:"priority,omitempty"`
+
+	// compiledParamsSchema and compiledOutputsSchema are compiled by Validate.
+	compiledParamsSchema  *jsonschema.Schema
+	compiledOutputsSchema *jsonschema.Schema
+}
+
+// Validate compiles the schemas and checks that the match conditions are valid. It must be called when the provisioner
+// is parsed since the params and outputs are validated against the compiled schemas.
+func (c *CommonFields) Validate() error {
+	var err error
+	if c.compiledParamsSchema, err = CompileSchema("params_schema", c.ResParamsSchema); err != nil {
+		return err
+	} else if c.compiledOutputsSchema, err = CompileSchema("outputs_schema", c.ResOutputsSchema); err != nil {
+		return err
+	}
+	if err := c.ResMatch.Validate(); err != nil {
+		return fmt.Errorf("invalid match: %w", err)
+	}
+	return nil
+}
+
+func (c *CommonFields) ParamsSchema() map[string]interface{} {
+	return c.ResParamsSchema
+}
+
+func (c *CommonFields) OutputsSchema() map[string]interface{} {
+	return c.ResOutputsSchema
+}
+
+func (c *CommonFields) CompiledParamsSchema() *jsonschema.Schema {
+	return c.compiledParamsSchema
+}
+
+func (c *CommonFields) CompiledOutputsSchema() *jsonschema.Schema {
+	return c.compiledOutputsSchema
+}
+
+func (c *CommonFields) Strict() bool {
+	return c.ResStrict == nil || *c.ResStrict
+}
+
+func (c *CommonFields) MatchConditions() *MatchConditions {
+	return c.ResMatch
+}
+
+func (c *CommonFields) Priority() int {
+	return c.ResPriority
+}
+
+var _ SchemaProvisioner = (*CommonFields)(nil)
+var _ StrictProvisioner = (*CommonFields)(nil)
+var _ ConditionalProvisioner = (*CommonFields)(nil)
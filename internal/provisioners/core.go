@@ -16,14 +16,18 @@ package provisioners
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
 	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/score-spec/score-go/framework"
 	score "github.com/score-spec/score-go/types"
+	rbacV1 "k8s.io/api/rbac/v1"
 
 	util "github.com/score-spec/score-k8s/internal"
 	"github.com/score-spec/score-k8s/internal/convert"
@@ -87,6 +91,9 @@ type ProvisionOutput struct {
 	ResourceOutputs map[string]interface{}   `json:"resource_outputs"`
 	SharedState     map[string]interface{}   `json:"shared_state"`
 	Manifests       []map[string]interface{} `json:"manifests"`
+	// RbacRules are the permissions in the namespace that the workloads using the resource need, for example to read a
+	// ConfigMap. They are granted to the ServiceAccount of each of these workloads with a Role and RoleBinding.
+	RbacRules []rbacV1.PolicyRule `json:"rbac_rules"`
 
 	// For testing and legacy reasons, built in provisioners can set a direct lookup function
 	OutputLookupFunc framework.OutputLookupFunc `json:"-"`
@@ -103,6 +110,126 @@ type Provisioner interface {
 	Description() string
 }
 
+// Deprovisioner is an optional interface implemented by provisioners that need to clean up a resource when it is no
+// longer referenced by any workload. The input contains the last state of the resource and only the shared state of the
+// returned output is applied.
+type Deprovisioner interface {
+	Deprovision(ctx context.Context, input *Input) (*ProvisionOutput, error)
+}
+
+// SharedStateProvisioner is implemented by provisioners that can declare that their output does not depend on the
+// shared state. Provisioners that do not implement it are assumed to read the shared state.
+type SharedStateProvisioner interface {
+	ReadsSharedState() bool
+}
+
+// readsSharedState returns whether the output of the provisioner may depend on the shared state.
+func readsSharedState(provisioner Provisioner) bool {
+	if p, ok := provisioner.(SharedStateProvisioner); ok {
+		return p.ReadsSharedState()
+	}
+	return true
+}
+
+// StrictProvisioner is implemented by provisioners that can opt out of checking the resource params against the
+// supported params and the resource outputs against the expected outputs. Provisioners that do not implement it are
+// always checked.
+type StrictProvisioner interface {
+	Strict() bool
+}
+
+// isStrict returns whether the params and outputs of the provisioner should be checked.
+func isStrict(provisioner Provisioner) bool {
+	if sp, ok := provisioner.(StrictProvisioner); ok {
+		return sp.Strict()
+	}
+	return true
+}
+
+// checkSupportedParams returns an error naming the params that are not in the supported params of the provisioner.
+// Provisioners without any supported params accept any params.
+func checkSupportedParams(resUid framework.ResourceUid, provisioner Provisioner, params map[string]interface{}) error {
+	supported := provisioner.Params()
+	if len(supported) == 0 || !isStrict(provisioner) {
+		return nil
+	}
+	var unknown []string
+	for _, k := range slices.Sorted(maps.Keys(params)) {
+		if !slices.Contains(supported, k) {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("resource '%s': provisioner '%s' does not support params '%s', supported params are '%s'",
+			resUid, provisioner.Uri(), strings.Join(unknown, "', '"), strings.Join(supported, "', '"))
+	}
+	return nil
+}
+
+// checkExpectedOutputs returns an error naming the expected outputs of the provisioner that are missing from the
+// output.
+func checkExpectedOutputs(resUid framework.ResourceUid, provisioner Provisioner, output *ProvisionOutput) error {
+	if !isStrict(provisioner) {
+		return nil
+	}
+	var missing []string
+	for _, k := range provisioner.Outputs() {
+		if _, ok := output.ResourceOutputs[k]; ok {
+			continue
+		} else if output.OutputLookupFunc != nil {
+			if _, err := output.OutputLookupFunc(k); err == nil {
+				continue
+			}
+		}
+		missing = append(missing, k)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("resource '%s': provisioner '%s' did not return expected outputs '%s'",
+			resUid, provisioner.Uri(), strings.Join(missing, "', '"))
+	}
+	return nil
+}
+
+// validateRbacRules checks that each rule can be granted by a namespaced Role.
+func validateRbacRules(rules []rbacV1.PolicyRule) error {
+	for i, rule := range rules {
+		if len(rule.Verbs) == 0 {
+			return fmt.Errorf("rbac_rules.%d: verbs must not be empty", i)
+		} else if len(rule.NonResourceURLs) > 0 {
+			return fmt.Errorf("rbac_rules.%d: non-resource urls cannot be granted by a Role", i)
+		} else if len(rule.Resources) == 0 {
+			return fmt.Errorf("rbac_rules.%d: resources must not be empty", i)
+		}
+	}
+	return nil
+}
+
+// ProvisionResource provisions a single resource from the input without a project state. The params are checked
+// against the supported params and params schema and the outputs against the expected outputs and outputs schema in
+// the same way as ProvisionResources. This is used to test provisioners against fixture inputs.
+func ProvisionResource(ctx context.Context, provisioner Provisioner, input *Input) (*ProvisionOutput, error) {
+	resUid := framework.ResourceUid(input.ResourceUid)
+	if err := checkSupportedParams(resUid, provisioner, input.ResourceParams); err != nil {
+		return nil, err
+	}
+	params, err := checkParamsSchema(input.ResourceUid, provisioner, input.ResourceParams)
+	if err != nil {
+		return nil, err
+	}
+	prepared := *input
+	prepared.ResourceParams = params
+	output, err := provisioner.Provision(ctx, &prepared)
+	if err != nil {
+		return nil, fmt.Errorf("resource '%s': failed to provision: %w", resUid, err)
+	} else if err := checkExpectedOutputs(resUid, provisioner, output); err != nil {
+		return nil, err
+	} else if err := checkOutputsSchema(input.ResourceUid, provisioner, output.ResourceOutputs); err != nil {
+		return nil, err
+	}
+	output.ProvisionerUri = provisioner.Uri()
+	return output, nil
+}
+
 type ephemeralProvisioner struct {
 	uri         string
 	matchUid    framework.ResourceUid
@@ -192,6 +319,12 @@ func (po *ProvisionOutput) ApplyToStateAndProject(state *project.State, resUid f
 	if po.SharedState != nil {
 		out.SharedState = util.PatchMap(state.SharedState, po.SharedState)
 	}
+	existing.Extras.SharedStateKeys = nil
+	for _, k := range slices.Sorted(maps.Keys(po.SharedState)) {
+		if po.SharedState[k] != nil {
+			existing.Extras.SharedStateKeys = append(existing.Extras.SharedStateKeys, k)
+		}
+	}
 
 	// Manifests must also always be updated.
 	if len(po.Manifests) > 0 {
@@ -200,6 +333,11 @@ func (po *ProvisionOutput) ApplyToStateAndProject(state *project.State, resUid f
 		existing.Extras.Manifests = make([]map[string]interface{}, 0)
 	}
 
+	if err := validateRbacRules(po.RbacRules); err != nil {
+		return nil, err
+	}
+	existing.Extras.RbacRules = po.RbacRules
+
 	out.Resources[resUid] = existing
 	return &out, nil
 }
@@ -234,46 +372,170 @@ func buildWorkloadServices(state *project.State) map[string]NetworkService {
 	return out
 }
 
+// ProvisionResources provisions the resources of the workloads one at a time in dependency order.
 func ProvisionResources(ctx context.Context, state *project.State, provisioners []Provisioner, namespace string) (*project.State, error) {
+	return ProvisionResourcesInParallel(ctx, state, provisioners, namespace, 1, 0)
+}
+
+// resourceDependencies returns the uids of the resources that are referenced by the params placeholders of each
+// resource. A shared resource depends on the resources referenced by any of the workloads that declare it.
+func resourceDependencies(state *project.State) (map[framework.ResourceUid]map[framework.ResourceUid]bool, error) {
+	out := make(map[framework.ResourceUid]map[framework.ResourceUid]bool)
+	for workloadName, workload := range state.Workloads {
+		for resName, res := range workload.Spec.Resources {
+			resUid := framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id)
+			if out[resUid] == nil {
+				out[resUid] = make(map[framework.ResourceUid]bool)
+			}
+			if res.Params == nil {
+				continue
+			}
+			if _, err := framework.Substitute((map[string]interface{})(res.Params), func(ref string) (string, error) {
+				if parts := framework.SplitRefParts(ref); len(parts) > 1 && parts[0] == "resources" {
+					if other, ok := workload.Spec.Resources[parts[1]]; ok {
+						out[resUid][framework.NewResourceUid(workloadName, parts[1], other.Type, other.Class, other.Id)] = true
+					}
+				}
+				return ref, nil
+			}); err != nil {
+				return nil, fmt.Errorf("workload '%s' resource '%s': %w", workloadName, resName, err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// provisionTask is a provisioning request for the resource at an index in the serial provisioning order.
+type provisionTask struct {
+	index       int
+	provisioner Provisioner
+	input       *Input
+	output      *ProvisionOutput
+	pending     bool
+	err         error
+}
+
+// provision runs the provisioner, cancelling it after the timeout when the timeout is greater than 0.
+func (t *provisionTask) provision(ctx context.Context, timeout time.Duration) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if t.output, t.err = t.provisioner.Provision(ctx, t.input); t.err != nil {
+		if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.err = fmt.Errorf("resource '%s': failed to provision within %s: %w", t.input.ResourceUid, timeout, t.err)
+			return
+		}
+		t.err = fmt.Errorf("resource '%s': failed to provision: %w", t.input.ResourceUid, t.err)
+	}
+}
+
+// ProvisionResourcesInParallel provisions the resources of the workloads with up to parallelism provisioning requests
+// in flight at a time. A resource is provisioned once the resources referenced by its params have been provisioned.
+//
+// The outputs are applied to the state one at a time in the same dependency order as a serial run. A resource whose
+// provisioner reads the shared state is only provisioned once the outputs of all the resources before it have been
+// applied, so that it sees the same shared state as in a serial run. Each resource is provisioned exactly once, and the
+// resulting state is identical to a serial run.
+//
+// When the timeout is greater than 0, each provisioning request is cancelled after the timeout.
+func ProvisionResourcesInParallel(ctx context.Context, state *project.State, provisioners []Provisioner, namespace string, parallelism int, timeout time.Duration) (*project.State, error) {
+	out, _, err := provisionResourcesInParallel(ctx, state, provisioners, namespace, parallelism, timeout, nil)
+	return out, err
+}
+
+// PendingOutputValue is the value of every output of a resource that is left pending by PreviewResourcesInParallel.
+const PendingOutputValue = "<pending>"
+
+// PreviewResourcesInParallel provisions the resources in the same way as ProvisionResourcesInParallel, except that a
+// resource that has not been provisioned before is left pending when skip returns true for its provisioner. This allows
+// the caller to avoid the side effects of provisioners that call external systems. A pending resource has no manifests
+// and each of its outputs is PendingOutputValue. The uids of the pending resources are returned in provisioning order.
+func PreviewResourcesInParallel(ctx context.Context, state *project.State, provisioners []Provisioner, namespace string, parallelism int, timeout time.Duration, skip func(Provisioner) bool) (*project.State, []framework.ResourceUid, error) {
+	return provisionResourcesInParallel(ctx, state, provisioners, namespace, parallelism, timeout, skip)
+}
+
+func provisionResourcesInParallel(ctx context.Context, state *project.State, provisioners []Provisioner, namespace string, parallelism int, timeout time.Duration, skip func(Provisioner) bool) (*project.State, []framework.ResourceUid, error) {
+	if parallelism < 1 {
+		return nil, nil, fmt.Errorf("parallelism must be at least 1")
+	}
 	out := state
+	var pending []framework.ResourceUid
 
 	// provision in sorted order
 	orderedResources, err := out.GetSortedResourceUids()
 	if err != nil {
-		return nil, fmt.Errorf("failed to determine sort order for provisioning: %w", err)
+		return nil, nil, fmt.Errorf("failed to determine sort order for provisioning: %w", err)
+	}
+	dependencies, err := resourceDependencies(out)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to determine sort order for provisioning: %w", err)
 	}
 
 	workloadServices := buildWorkloadServices(state)
 
-	for _, resUid := range orderedResources {
+	// matchInput builds the input used to match a provisioner to the resource.
+	matchInput := func(resUid framework.ResourceUid) *Input {
 		resState := out.Resources[resUid]
-		provisionerIndex := slices.IndexFunc(provisioners, func(provisioner Provisioner) bool {
-			return provisioner.Match(resUid)
-		})
-		if provisionerIndex < 0 {
-			return nil, fmt.Errorf("resource '%s' is not supported by any provisioner. "+
+		return &Input{
+			ResourceUid:      string(resUid),
+			ResourceParams:   resState.Params,
+			ResourceMetadata: resState.Metadata,
+			SourceWorkload:   resState.SourceWorkload,
+			Namespace:        namespace,
+		}
+	}
+
+	// prepare builds the provisioning request for the resource from the current state. Any failure is returned as
+	// part of the task so that errors are reported in the same order as a serial run.
+	prepare := func(index int) *provisionTask {
+		task := &provisionTask{index: index}
+		resUid := orderedResources[index]
+		resState := out.Resources[resUid]
+		var ok bool
+		if task.provisioner, ok = MatchProvisioner(provisioners, matchInput(resUid)); !ok {
+			task.err = fmt.Errorf("resource '%s' is not supported by any provisioner. "+
 				"Please implement a custom resource provisioner to support this resource type.", resUid)
+			return task
 		}
-		provisioner := provisioners[provisionerIndex]
-		if resState.ProvisionerUri != "" && resState.ProvisionerUri != provisioner.Uri() {
-			return nil, fmt.Errorf("resource '%s' was previously provisioned by a different provider - undefined behavior", resUid)
+		if resState.ProvisionerUri != "" && resState.ProvisionerUri != task.provisioner.Uri() {
+			task.err = fmt.Errorf("resource '%s' was previously provisioned by a different provider - undefined behavior", resUid)
+			return task
+		}
+		if skip != nil && resState.ProvisionerUri == "" && skip(task.provisioner) {
+			task.pending = true
+			task.output = &ProvisionOutput{OutputLookupFunc: func(keys ...string) (interface{}, error) {
+				return PendingOutputValue, nil
+			}}
+			return task
 		}
 
 		var params map[string]interface{}
 		if len(resState.Params) > 0 {
 			resOutputs, err := out.GetResourceOutputForWorkload(resState.SourceWorkload)
 			if err != nil {
-				return nil, fmt.Errorf("failed to find resource params for resource '%s': %w", resUid, err)
+				task.err = fmt.Errorf("failed to find resource params for resource '%s': %w", resUid, err)
+				return task
 			}
 			sf := framework.BuildSubstitutionFunction(out.Workloads[resState.SourceWorkload].Spec.Metadata, resOutputs)
 			rawParams, err := framework.Substitute(resState.Params, sf)
 			if err != nil {
-				return nil, fmt.Errorf("failed to substitute params for resource '%s': %w", resUid, err)
+				task.err = fmt.Errorf("failed to substitute params for resource '%s': %w", resUid, err)
+				return task
 			}
 			params = rawParams.(map[string]interface{})
 		}
+		if err := checkSupportedParams(resUid, task.provisioner, resState.Params); err != nil {
+			task.err = err
+			return task
+		}
+		if params, err = checkParamsSchema(string(resUid), task.provisioner, params); err != nil {
+			task.err = err
+			return task
+		}
 
-		output, err := provisioner.Provision(ctx, &Input{
+		task.input = &Input{
 			ResourceGuid:     resState.Guid,
 			ResourceUid:      string(resUid),
 			ResourceType:     resUid.Type(),
@@ -287,17 +549,189 @@ func ProvisionResources(ctx context.Context, state *project.State, provisioners
 			WorkloadServices: workloadServices,
 			SharedState:      out.SharedState,
 			Namespace:        namespace,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("resource '%s': failed to provision: %w", resUid, err)
 		}
+		return task
+	}
 
-		output.ProvisionerUri = provisioner.Uri()
-		out, err = output.ApplyToStateAndProject(out, resUid)
-		if err != nil {
-			return nil, fmt.Errorf("resource '%s': failed to apply outputs: %w", resUid, err)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// the results channel is large enough for every task so that in-flight requests never block after an error
+	results := make(chan *provisionTask, len(orderedResources))
+	finished := make(map[int]*provisionTask)
+	started := make([]bool, len(orderedResources))
+	var running int
+
+	// ready returns whether the resources referenced by the params of the resource have been provisioned, and for a
+	// resource that reads the shared state, whether all the resources before it have been provisioned. Only
+	// dependencies earlier in the order are considered so that a resource is never started later than in a serial run.
+	positions := make(map[framework.ResourceUid]int, len(orderedResources))
+	sharedStateReaders := make([]bool, len(orderedResources))
+	for i, resUid := range orderedResources {
+		positions[resUid] = i
+		if provisioner, ok := MatchProvisioner(provisioners, matchInput(resUid)); ok {
+			sharedStateReaders[i] = readsSharedState(provisioner)
 		}
 	}
+	ready := func(index int, next int) bool {
+		if sharedStateReaders[index] && index > next {
+			return false
+		}
+		for dep := range dependencies[orderedResources[index]] {
+			if p, ok := positions[dep]; ok && p < index && p >= next {
+				return false
+			}
+		}
+		return true
+	}
 
-	return out, nil
+	for next := 0; next < len(orderedResources); {
+		// apply the next output in order if it is available
+		if task, ok := finished[next]; ok {
+			delete(finished, next)
+			resUid := orderedResources[next]
+			if task.err != nil {
+				return nil, nil, task.err
+			} else if task.pending {
+				pending = append(pending, resUid)
+			} else if err := checkExpectedOutputs(resUid, task.provisioner, task.output); err != nil {
+				return nil, nil, err
+			} else if err := checkOutputsSchema(string(resUid), task.provisioner, task.output.ResourceOutputs); err != nil {
+				return nil, nil, err
+			}
+			task.output.ProvisionerUri = task.provisioner.Uri()
+			if out, err = task.output.ApplyToStateAndProject(out, resUid); err != nil {
+				return nil, nil, fmt.Errorf("resource '%s': failed to apply outputs: %w", resUid, err)
+			}
+			next++
+			continue
+		}
+
+		// start any resources whose dependencies have been provisioned, in order
+		for index := next; index < len(orderedResources) && running < parallelism; index++ {
+			if started[index] || !ready(index, next) {
+				continue
+			}
+			started[index] = true
+			task := prepare(index)
+			if task.err != nil || task.pending {
+				finished[index] = task
+				continue
+			}
+			running++
+			go func() {
+				task.provision(ctx, timeout)
+				results <- task
+			}()
+		}
+
+		if _, ok := finished[next]; !ok {
+			task := <-results
+			running--
+			finished[task.index] = task
+		}
+	}
+
+	return out, pending, nil
+}
+
+// UnreferencedResourceUids returns the sorted uids of the resources in the state that are no longer declared by any
+// workload.
+func UnreferencedResourceUids(state *project.State) []framework.ResourceUid {
+	referenced := make(map[framework.ResourceUid]bool)
+	for workloadName, workload := range state.Workloads {
+		for resName, res := range workload.Spec.Resources {
+			referenced[framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id)] = true
+		}
+	}
+	out := make([]framework.ResourceUid, 0)
+	for resUid := range state.Resources {
+		if !referenced[resUid] {
+			out = append(out, resUid)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// deprovision runs the deprovisioner, cancelling it after the timeout when the timeout is greater than 0.
+func deprovision(ctx context.Context, deprovisioner Deprovisioner, input *Input, timeout time.Duration) (*ProvisionOutput, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	output, err := deprovisioner.Deprovision(ctx, input)
+	if err != nil {
+		if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("resource '%s': failed to deprovision within %s: %w", input.ResourceUid, timeout, err)
+		}
+		return nil, fmt.Errorf("resource '%s': failed to deprovision: %w", input.ResourceUid, err)
+	}
+	return output, nil
+}
+
+// DeprovisionResources calls the deprovision hook of the provisioner that last provisioned each of the given resources
+// and then removes the resource from the state along with the top level shared state keys it set that no remaining
+// resource has also set. When the timeout is greater than 0, each deprovisioning request is cancelled after the
+// timeout.
+func DeprovisionResources(ctx context.Context, state *project.State, resUids []framework.ResourceUid, provisioners []Provisioner, namespace string, timeout time.Duration) (*project.State, error) {
+	out := *state
+	out.Resources = maps.Clone(state.Resources)
+	out.SharedState = maps.Clone(state.SharedState)
+
+	workloadServices := buildWorkloadServices(state)
+
+	for _, resUid := range resUids {
+		resState, ok := out.Resources[resUid]
+		if !ok {
+			continue
+		}
+
+		if resState.ProvisionerUri != "" {
+			provisionerIndex := slices.IndexFunc(provisioners, func(provisioner Provisioner) bool {
+				return provisioner.Uri() == resState.ProvisionerUri
+			})
+			if provisionerIndex < 0 {
+				return nil, fmt.Errorf("resource '%s': cannot deprovision because provisioner '%s' no longer exists", resUid, resState.ProvisionerUri)
+			}
+			if deprovisioner, ok := provisioners[provisionerIndex].(Deprovisioner); ok {
+				output, err := deprovision(ctx, deprovisioner, &Input{
+					ResourceGuid:     resState.Guid,
+					ResourceUid:      string(resUid),
+					ResourceType:     resUid.Type(),
+					ResourceClass:    resUid.Class(),
+					ResourceId:       resUid.Id(),
+					ResourceMetadata: resState.Metadata,
+					ResourceState:    resState.State,
+					SourceWorkload:   resState.SourceWorkload,
+					WorkloadServices: workloadServices,
+					SharedState:      out.SharedState,
+					Namespace:        namespace,
+				}, timeout)
+				if err != nil {
+					return nil, err
+				}
+				if output != nil && output.SharedState != nil {
+					out.SharedState = util.PatchMap(out.SharedState, output.SharedState)
+				}
+			}
+		}
+
+		delete(out.Resources, resUid)
+		slog.Info(fmt.Sprintf("Deprovisioned resource '%s'", resUid))
+
+		// Shared state keys are only removed when no remaining resource has set them.
+		for _, k := range resState.Extras.SharedStateKeys {
+			if slices.ContainsFunc(slices.Collect(maps.Values(out.Resources)), func(other framework.ScoreResourceState[project.ResourceExtras]) bool {
+				return slices.Contains(other.Extras.SharedStateKeys, k)
+			}) {
+				continue
+			}
+			if _, ok := out.SharedState[k]; ok {
+				delete(out.SharedState, k)
+				slog.Info(fmt.Sprintf("Removed shared state '%s' set by resource '%s'", k, resUid))
+			}
+		}
+	}
+	return &out, nil
 }
@@ -15,11 +15,19 @@
 package provisioners
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/score-spec/score-go/framework"
+	score "github.com/score-spec/score-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	rbacV1 "k8s.io/api/rbac/v1"
 
 	"github.com/score-spec/score-k8s/internal/project"
 )
@@ -73,9 +81,290 @@ func TestApplyToStateAndProject(t *testing.T) {
 						"data":       map[string]interface{}{"key": "value"},
 					},
 				},
+				SharedStateKeys: []string{"i"},
 			},
 		}, afterState.Resources[resUid])
 		assert.Equal(t, map[string]interface{}{"i": "j"}, afterState.SharedState)
 	})
 
+	t.Run("set rbac rules", func(t *testing.T) {
+		rules := []rbacV1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}}
+		output := &ProvisionOutput{RbacRules: rules}
+		afterState, err := output.ApplyToStateAndProject(startState, resUid)
+		require.NoError(t, err)
+		assert.Equal(t, rules, afterState.Resources[resUid].Extras.RbacRules)
+	})
+
+	t.Run("invalid rbac rules", func(t *testing.T) {
+		for _, tc := range []struct {
+			rule rbacV1.PolicyRule
+			err  string
+		}{
+			{rbacV1.PolicyRule{Resources: []string{"configmaps"}}, "rbac_rules.0: verbs must not be empty"},
+			{rbacV1.PolicyRule{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}}, "rbac_rules.0: non-resource urls cannot be granted by a Role"},
+			{rbacV1.PolicyRule{APIGroups: []string{""}, Verbs: []string{"get"}}, "rbac_rules.0: resources must not be empty"},
+		} {
+			output := &ProvisionOutput{RbacRules: []rbacV1.PolicyRule{tc.rule}}
+			_, err := output.ApplyToStateAndProject(startState, resUid)
+			assert.EqualError(t, err, tc.err)
+		}
+	})
+}
+
+func TestDeprovisionResources(t *testing.T) {
+	keptUid := framework.NewResourceUid("w", "kept", "t", nil, nil)
+	goneUid := framework.NewResourceUid("w", "gone", "t", nil, nil)
+	startState := &project.State{
+		Resources: map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+			keptUid: {ProvisionerUri: "template://example", Extras: project.ResourceExtras{SharedStateKeys: []string{"common"}}},
+			goneUid: {ProvisionerUri: "template://example", Extras: project.ResourceExtras{SharedStateKeys: []string{"common", "own"}}},
+		},
+		SharedState: map[string]interface{}{"common": true, "own": true, "other": true},
+	}
+	assert.Equal(t, []framework.ResourceUid{goneUid, keptUid}, UnreferencedResourceUids(startState))
+
+	t.Run("missing provisioner", func(t *testing.T) {
+		_, err := DeprovisionResources(context.Background(), startState, []framework.ResourceUid{goneUid}, nil, "", 0)
+		assert.EqualError(t, err, "resource 't.default#w.gone': cannot deprovision because provisioner 'template://example' no longer exists")
+	})
+
+	t.Run("removes resource and shared state", func(t *testing.T) {
+		p := NewEphemeralProvisioner("template://example", keptUid, nil)
+		afterState, err := DeprovisionResources(context.Background(), startState, []framework.ResourceUid{goneUid}, []Provisioner{p}, "", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []framework.ResourceUid{keptUid}, slices.Collect(maps.Keys(afterState.Resources)))
+		assert.Equal(t, map[string]interface{}{"common": true, "other": true}, afterState.SharedState)
+		assert.Len(t, startState.Resources, 2)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		p := &blockingDeprovisioner{NewEphemeralProvisioner("template://example", keptUid, nil)}
+		_, err := DeprovisionResources(context.Background(), startState, []framework.ResourceUid{goneUid}, []Provisioner{p}, "", 50*time.Millisecond)
+		assert.EqualError(t, err, "resource 't.default#w.gone': failed to deprovision within 50ms: context deadline exceeded")
+	})
+}
+
+type blockingDeprovisioner struct {
+	Provisioner
+}
+
+func (b *blockingDeprovisioner) Deprovision(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func buildParallelTestState(t *testing.T) *project.State {
+	t.Helper()
+	resources := map[string]score.Resource{
+		"dependent": {Type: "t", Params: map[string]interface{}{"from": "${resources.r0.value}"}},
+	}
+	for i := 0; i < 6; i++ {
+		resources[fmt.Sprintf("r%d", i)] = score.Resource{Type: "t"}
+	}
+	state, err := new(project.State).WithWorkload(&score.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]score.Container{"main": {Image: "nginx"}},
+		Resources:  resources,
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+	return state
+}
+
+// forEachResource returns an ephemeral provisioner for each resource in the state that calls the same function.
+func forEachResource(state *project.State, inner func(ctx context.Context, input *Input) (*ProvisionOutput, error)) []Provisioner {
+	out := make([]Provisioner, 0, len(state.Resources))
+	for resUid := range state.Resources {
+		out = append(out, NewEphemeralProvisioner("ephemeral://t", resUid, inner))
+	}
+	return out
+}
+
+func TestProvisionResourcesInParallel(t *testing.T) {
+	startState := buildParallelTestState(t)
+
+	// newProvisioner returns a provisioner that records the params and optionally counts the provisioned resources in
+	// the shared state, along with the number of calls and the maximum number of concurrent calls. Provisioners that
+	// do not count declare that they do not read the shared state.
+	newProvisioner := func(countInSharedState bool) ([]Provisioner, *atomic.Int32, *atomic.Int32) {
+		var calls, running, maxRunning atomic.Int32
+		p := forEachResource(startState, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+			calls.Add(1)
+			r := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if r <= m || maxRunning.CompareAndSwap(m, r) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			out := &ProvisionOutput{
+				ResourceState:   map[string]interface{}{"params": input.ResourceParams},
+				ResourceOutputs: map[string]interface{}{"value": input.ResourceUid},
+			}
+			if countInSharedState {
+				count, _ := input.SharedState["count"].(int)
+				out.ResourceState["count"] = count
+				out.SharedState = map[string]interface{}{"count": count + 1}
+			}
+			return out, nil
+		})
+		if !countInSharedState {
+			for i := range p {
+				p[i] = &nonSharedStateProvisioner{p[i]}
+			}
+		}
+		return p, &calls, &maxRunning
+	}
+	t.Run("independent resources run concurrently", func(t *testing.T) {
+		p, calls, maxRunning := newProvisioner(false)
+		afterState, err := ProvisionResourcesInParallel(context.Background(), startState, p, "", 3, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), calls.Load())
+		assert.Equal(t, int32(3), maxRunning.Load())
+		assert.Equal(t, map[string]interface{}{"from": "t.default#w.r0"}, afterState.Resources["t.default#w.dependent"].State["params"])
+	})
+
+	t.Run("identical to serial run", func(t *testing.T) {
+		serialProvisioner, _, _ := newProvisioner(true)
+		serialState, err := ProvisionResources(context.Background(), startState, serialProvisioner, "")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"count": 7}, serialState.SharedState)
+
+		parallelProvisioner, calls, _ := newProvisioner(true)
+		parallelState, err := ProvisionResourcesInParallel(context.Background(), startState, parallelProvisioner, "", 4, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), calls.Load())
+		assert.Equal(t, serialState.SharedState, parallelState.SharedState)
+		assert.Equal(t, serialState.Resources, parallelState.Resources)
+	})
+
+	t.Run("resources that do not read the shared state can still write it", func(t *testing.T) {
+		var calls atomic.Int32
+		p := forEachResource(startState, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+			calls.Add(1)
+			return &ProvisionOutput{
+				ResourceOutputs: map[string]interface{}{"value": input.ResourceUid},
+				SharedState:     map[string]interface{}{"last": input.ResourceUid},
+			}, nil
+		})
+		for i := range p {
+			p[i] = &nonSharedStateProvisioner{p[i]}
+		}
+		serialState, err := ProvisionResources(context.Background(), startState, p, "")
+		require.NoError(t, err)
+		parallelState, err := ProvisionResourcesInParallel(context.Background(), startState, p, "", 4, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int32(14), calls.Load())
+		assert.Equal(t, serialState.SharedState, parallelState.SharedState)
+	})
+
+	t.Run("errors are reported in order", func(t *testing.T) {
+		p := forEachResource(startState, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+			if input.ResourceUid == "t.default#w.r0" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			if input.ResourceUid != "t.default#w.r4" {
+				return nil, fmt.Errorf("failed %s", input.ResourceUid)
+			}
+			return &ProvisionOutput{}, nil
+		})
+		_, err := ProvisionResourcesInParallel(context.Background(), startState, p, "", 4, 0)
+		assert.EqualError(t, err, "resource 't.default#w.r0': failed to provision: failed t.default#w.r0")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		p := forEachResource(startState, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+			if input.ResourceUid == "t.default#w.r1" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &ProvisionOutput{}, nil
+		})
+		_, err := ProvisionResourcesInParallel(context.Background(), startState, p, "", 4, 50*time.Millisecond)
+		assert.EqualError(t, err, "resource 't.default#w.r1': failed to provision within 50ms: context deadline exceeded")
+	})
+
+	t.Run("invalid parallelism", func(t *testing.T) {
+		_, err := ProvisionResourcesInParallel(context.Background(), startState, nil, "", 0, 0)
+		assert.EqualError(t, err, "parallelism must be at least 1")
+	})
+
+	t.Run("preview leaves skipped resources pending", func(t *testing.T) {
+		p, calls, _ := newProvisioner(false)
+		skip := func(provisioner Provisioner) bool {
+			return provisioner.Match("t.default#w.r0")
+		}
+		afterState, pending, err := PreviewResourcesInParallel(context.Background(), startState, p, "", 4, 0, skip)
+		require.NoError(t, err)
+		assert.Equal(t, []framework.ResourceUid{"t.default#w.r0"}, pending)
+		assert.Equal(t, int32(6), calls.Load())
+		assert.Empty(t, afterState.Resources["t.default#w.r0"].Extras.Manifests)
+		assert.Equal(t, map[string]interface{}{"from": PendingOutputValue}, afterState.Resources["t.default#w.dependent"].State["params"])
+
+		// resources that were provisioned before are never skipped
+		_, pending, err = PreviewResourcesInParallel(context.Background(), afterState, p, "", 4, 0, skip)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+		assert.Equal(t, int32(13), calls.Load())
+	})
+}
+
+type nonSharedStateProvisioner struct {
+	Provisioner
+}
+
+func (n *nonSharedStateProvisioner) ReadsSharedState() bool {
+	return false
+}
+
+type nonStrictProvisioner struct {
+	Provisioner
+}
+
+func (n *nonStrictProvisioner) Strict() bool {
+	return false
+}
+
+func TestProvisionResources_contracts(t *testing.T) {
+	startState := buildParallelTestState(t)
+	resUid := framework.ResourceUid("t.default#w.dependent")
+	newProvisioner := func(params []string, outputs []string) *ephemeralProvisioner {
+		return &ephemeralProvisioner{
+			uri:      "ephemeral://contract",
+			matchUid: resUid,
+			params:   params,
+			outputs:  outputs,
+			provision: func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+				return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"value": "x"}}, nil
+			},
+		}
+	}
+	// the other resources are provisioned by a provisioner without any contract
+	others := forEachResource(startState, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"value": "x"}}, nil
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		_, err := ProvisionResources(context.Background(), startState, append([]Provisioner{newProvisioner([]string{"from", "other"}, []string{"value"})}, others...), "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("unsupported params", func(t *testing.T) {
+		_, err := ProvisionResources(context.Background(), startState, append([]Provisioner{newProvisioner([]string{"other", "thing"}, nil)}, others...), "")
+		assert.EqualError(t, err, "resource 't.default#w.dependent': provisioner 'ephemeral://contract' does not support params 'from', supported params are 'other', 'thing'")
+	})
+
+	t.Run("missing outputs", func(t *testing.T) {
+		_, err := ProvisionResources(context.Background(), startState, append([]Provisioner{newProvisioner(nil, []string{"value", "host", "port"})}, others...), "")
+		assert.EqualError(t, err, "resource 't.default#w.dependent': provisioner 'ephemeral://contract' did not return expected outputs 'host', 'port'")
+	})
+
+	t.Run("not strict", func(t *testing.T) {
+		p := &nonStrictProvisioner{newProvisioner([]string{"other"}, []string{"host"})}
+		_, err := ProvisionResources(context.Background(), startState, append([]Provisioner{p}, others...), "")
+		assert.NoError(t, err)
+	})
 }
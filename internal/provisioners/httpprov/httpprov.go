@@ -0,0 +1,320 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpprov
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+
+	"github.com/score-spec/score-k8s/internal/provisioners"
+	"github.com/score-spec/score-k8s/internal/version"
+)
+
+const (
+	// DefaultTimeout is the timeout for each request when the provisioner does not set one.
+	DefaultTimeout = 30 * time.Second
+
+	// maxErrorBodyLength is the number of bytes of a failed response body that are included in the error.
+	maxErrorBodyLength = 1024
+)
+
+// retryBackoff is the delay before the first retry, it doubles for each subsequent retry.
+var retryBackoff = time.Second
+
+type Provisioner struct {
+	ProvisionerUri string  `yaml:"uri"`
+	ResType        string  `yaml:"type"`
+	ResClass       *string `yaml:"class,omitempty"`
+	ResId          *string `yaml:"id,omitempty"`
+	ResDescription string  `yaml:"description,omitempty"`
+
+	// Headers are added to each request. Values may reference environment variables as $NAME or ${NAME} so that
+	// credentials do not need to be stored in the provisioners file.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Timeout is the duration, like 10s, after which each request is cancelled.
+	Timeout string `yaml:"timeout,omitempty"`
+	// Retries is the number of times a request is retried after a connection error, a timeout, or a 429 or 5xx status.
+	Retries int `yaml:"retries,omitempty"`
+
+	// SupportedParams is a list of parameters that the provisioner expects to be passed in.
+	SupportedParams []string `yaml:"supported_params,omitempty"`
+
+	// ExpectedOutputs is a list of expected outputs that the provisioner should return.
+	ExpectedOutputs []string `yaml:"expected_outputs,omitempty"`
+
+	// CommonFields holds the params and outputs schemas, strict, match, and priority fields.
+	provisioners.CommonFields `yaml:",inline"`
+
+	// ResReadsSharedState can be set to false when the provisioner does not read the shared state, so that the resource
+	// can be provisioned in parallel with the resources before it.
+	ResReadsSharedState *bool `yaml:"reads_shared_state,omitempty"`
+
+	timeout time.Duration
+}
+
+func (p *Provisioner) Description() string {
+	return p.ResDescription
+}
+
+func (p *Provisioner) Uri() string {
+	return p.ProvisionerUri
+}
+
+func (p *Provisioner) Class() string {
+	if p.ResClass == nil {
+		return "(any)"
+	}
+	return *p.ResClass
+}
+
+func (p *Provisioner) Type() string {
+	return p.ResType
+}
+
+func (p *Provisioner) Params() []string {
+	if p.SupportedParams == nil {
+		return []string{}
+	}
+	params := make([]string, len(p.SupportedParams))
+	copy(params, p.SupportedParams)
+	slices.Sort(params)
+	return params
+}
+
+func (p *Provisioner) Outputs() []string {
+	if p.ExpectedOutputs == nil {
+		return []string{}
+	}
+	outputs := make([]string, len(p.ExpectedOutputs))
+	copy(outputs, p.ExpectedOutputs)
+	slices.Sort(outputs)
+	return outputs
+}
+
+func (p *Provisioner) ReadsSharedState() bool {
+	return p.ResReadsSharedState == nil || *p.ResReadsSharedState
+}
+
+func (p *Provisioner) Match(resUid framework.ResourceUid) bool {
+	if resUid.Type() != p.ResType {
+		return false
+	} else if p.ResClass != nil && resUid.Class() != *p.ResClass {
+		return false
+	} else if p.ResId != nil && resUid.Id() != *p.ResId {
+		return false
+	}
+	return true
+}
+
+// buildHeaders expands the environment variable references in the configured header values.
+func (p *Provisioner) buildHeaders() (http.Header, error) {
+	out := make(http.Header, len(p.Headers)+3)
+	out.Set("Content-Type", "application/json")
+	out.Set("Accept", "application/json")
+	out.Set("User-Agent", "score-k8s/"+version.Version)
+	for _, name := range slices.Sorted(maps.Keys(p.Headers)) {
+		var missing []string
+		value := os.Expand(p.Headers[name], func(k string) string {
+			v, ok := os.LookupEnv(k)
+			if !ok {
+				missing = append(missing, k)
+			}
+			return v
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("header '%s' references unset environment variable '%s'", name, missing[0])
+		}
+		out.Set(name, value)
+	}
+	return out, nil
+}
+
+// retryableError marks a failed request that may succeed when it is retried.
+type retryableError struct {
+	error
+}
+
+// statusError is a response with a status code outside of the 2xx range.
+type statusError struct {
+	statusCode int
+	error
+}
+
+// request sends the json input to the provisioner uri and returns the response body of a successful response.
+func (p *Provisioner) request(ctx context.Context, client *http.Client, method string, headers http.Header, rawInput []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, p.ProvisionerUri, bytes.NewReader(rawInput))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header = headers.Clone()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, retryableError{fmt.Errorf("request failed: %w", err)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, retryableError{fmt.Errorf("failed to read response: %w", err)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(body) > maxErrorBodyLength {
+			body = append(body[:maxErrorBodyLength], []byte("...")...)
+		}
+		err := statusError{resp.StatusCode, fmt.Errorf("unexpected response status %s: %s", resp.Status, bytes.TrimSpace(body))}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return nil, retryableError{err}
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+// send sends the json input to the provisioner uri with the method, retrying failed requests, and returns the response
+// body of the first successful response.
+func (p *Provisioner) send(ctx context.Context, method string, input *provisioners.Input) ([]byte, error) {
+	headers, err := p.buildHeaders()
+	if err != nil {
+		return nil, err
+	}
+	rawInput, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json input: %w", err)
+	}
+
+	client := &http.Client{}
+	var body []byte
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		slog.Debug(fmt.Sprintf("Sending %s request %d to http provisioner '%s'", method, attempt+1, p.ProvisionerUri))
+		if body, err = p.request(ctx, client, method, headers, rawInput); err == nil {
+			break
+		} else if !errors.As(err, new(retryableError)) {
+			return nil, fmt.Errorf("http provisioner request failed: %w", err)
+		} else if attempt >= p.Retries {
+			return nil, fmt.Errorf("http provisioner request failed after %d attempts: %w", attempt+1, err)
+		}
+		slog.Warn(fmt.Sprintf("Request to http provisioner '%s' failed, retrying in %s: %v", p.ProvisionerUri, backoff, err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("http provisioner request failed after %d attempts: %w", attempt+1, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return body, nil
+}
+
+// decodeOutput decodes the json output of the provisioner and rejects any unknown fields.
+func decodeOutput(body []byte) (*provisioners.ProvisionOutput, error) {
+	var output provisioners.ProvisionOutput
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&output); err != nil {
+		slog.Debug("Output from http provisioner:\n" + string(body))
+		return nil, fmt.Errorf("failed to decode output from http provisioner: %w", err)
+	}
+	return &output, nil
+}
+
+// Provision sends the json input as a POST request to the provisioner uri and decodes the output from the response.
+func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	body, err := p.send(ctx, http.MethodPost, input)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOutput(body)
+}
+
+// Deprovision sends the json input with the last state of the resource as a DELETE request to the provisioner uri when
+// the resource is no longer referenced by any workload. An empty response body means there are no shared state
+// modifications. Services that do not support deprovisioning can respond with 404 or 405, the resource is then removed
+// from the state without any modifications.
+func (p *Provisioner) Deprovision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	body, err := p.send(ctx, http.MethodDelete, input)
+	if se := new(statusError); errors.As(err, se) && (se.statusCode == http.StatusNotFound || se.statusCode == http.StatusMethodNotAllowed) {
+		slog.Debug(fmt.Sprintf("Skipping deprovision for http provisioner '%s' that responded with status %d", p.ProvisionerUri, se.statusCode))
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	return decodeOutput(body)
+}
+
+func Parse(raw map[string]interface{}) (*Provisioner, error) {
+	p := new(Provisioner)
+	intermediate, _ := yaml.Marshal(raw)
+	dec := yaml.NewDecoder(bytes.NewReader(intermediate))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.ProvisionerUri == "" {
+		return nil, fmt.Errorf("uri not set")
+	} else if p.ResType == "" {
+		return nil, fmt.Errorf("type not set")
+	}
+	if err := p.CommonFields.Validate(); err != nil {
+		return nil, err
+	}
+
+	parts, err := url.Parse(p.ProvisionerUri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	} else if parts.Scheme != "http" && parts.Scheme != "https" {
+		return nil, fmt.Errorf("http provisioner uri must have an http or https scheme")
+	} else if parts.Host == "" {
+		return nil, fmt.Errorf("http provisioner uri must have a host")
+	} else if parts.User != nil {
+		return nil, fmt.Errorf("http provisioner uri cannot contain user info, use headers instead")
+	}
+
+	p.timeout = DefaultTimeout
+	if p.Timeout != "" {
+		if p.timeout, err = time.ParseDuration(p.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		} else if p.timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout: must be greater than 0")
+		}
+	}
+	if p.Retries < 0 {
+		return nil, fmt.Errorf("invalid retries: must not be negative")
+	}
+
+	return p, nil
+}
+
+var _ provisioners.Provisioner = (*Provisioner)(nil)
+var _ provisioners.SchemaProvisioner = (*Provisioner)(nil)
+var _ provisioners.ConditionalProvisioner = (*Provisioner)(nil)
+var _ provisioners.SharedStateProvisioner = (*Provisioner)(nil)
+var _ provisioners.Deprovisioner = (*Provisioner)(nil)
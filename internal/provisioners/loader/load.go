@@ -32,6 +32,7 @@ import (
 
 	"github.com/score-spec/score-k8s/internal/provisioners"
 	"github.com/score-spec/score-k8s/internal/provisioners/cmdprov"
+	"github.com/score-spec/score-k8s/internal/provisioners/httpprov"
 	"github.com/score-spec/score-k8s/internal/provisioners/templateprov"
 )
 
@@ -67,6 +68,13 @@ func LoadProvisioners(raw []byte) ([]provisioners.Provisioner, error) {
 				slog.Debug(fmt.Sprintf("Loaded provisioner %s", p.Uri()))
 				out = append(out, p)
 			}
+		case "http", "https":
+			if p, err := httpprov.Parse(m); err != nil {
+				return nil, fmt.Errorf("%d: %s: failed to parse: %w", i, uri, err)
+			} else {
+				slog.Debug(fmt.Sprintf("Loaded provisioner %s", p.Uri()))
+				out = append(out, p)
+			}
 		default:
 			return nil, fmt.Errorf("%d: unsupported provisioner type '%s'", i, u.Scheme)
 		}
@@ -76,26 +84,37 @@ func LoadProvisioners(raw []byte) ([]provisioners.Provisioner, error) {
 
 // LoadProvisionersFromDirectory loads all providers we can find in files that end in the common suffix.
 func LoadProvisionersFromDirectory(path string, suffix string) ([]provisioners.Provisioner, error) {
+	out, _, err := LoadProvisionersFromDirectoryWithSources(path, suffix)
+	return out, err
+}
+
+// LoadProvisionersFromDirectoryWithSources loads all providers like LoadProvisionersFromDirectory and also returns the
+// name of the file that each provisioner was loaded from.
+func LoadProvisionersFromDirectoryWithSources(path string, suffix string) ([]provisioners.Provisioner, []string, error) {
 	slog.Debug(fmt.Sprintf("Loading providers with suffix %s in directory '%s'", suffix, path))
 	items, err := os.ReadDir(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	out := make([]provisioners.Provisioner, 0)
+	sources := make([]string, 0)
 	for _, item := range items {
 		if !item.IsDir() && strings.HasSuffix(item.Name(), suffix) {
 			raw, err := os.ReadFile(filepath.Join(path, item.Name()))
 			if err != nil {
-				return nil, fmt.Errorf("failed to read '%s': %w", item.Name(), err)
+				return nil, nil, fmt.Errorf("failed to read '%s': %w", item.Name(), err)
 			}
 			p, err := LoadProvisioners(raw)
 			if err != nil {
-				return nil, fmt.Errorf("failed to load '%s': %w", item.Name(), err)
+				return nil, nil, fmt.Errorf("failed to load '%s': %w", item.Name(), err)
 			}
 			out = append(out, p...)
+			for range p {
+				sources = append(sources, item.Name())
+			}
 		}
 	}
-	return out, nil
+	return out, sources, nil
 }
 
 // SaveProvisionerToDirectory saves the provisioner content (data) from the provisionerUrl to a new provisioners file
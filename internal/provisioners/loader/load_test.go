@@ -63,6 +63,21 @@ func TestLoadProvisioners(t *testing.T) {
 		assert.True(t, p[0].Match(framework.NewResourceUid("w", "r", "thing", nil, internal.Ref("specific"))))
 	})
 
+	t.Run("nominal http", func(t *testing.T) {
+		p, err := LoadProvisioners([]byte(`
+- uri: https://provisioner.example.com/provision
+  type: thing
+  headers:
+    Authorization: Bearer ${TOKEN}
+  timeout: 10s
+  retries: 2
+`))
+		require.NoError(t, err)
+		assert.Len(t, p, 1)
+		assert.Equal(t, "https://provisioner.example.com/provision", p[0].Uri())
+		assert.True(t, p[0].Match(framework.NewResourceUid("w", "r", "thing", nil, nil)))
+	})
+
 	t.Run("unknown schema", func(t *testing.T) {
 		_, err := LoadProvisioners([]byte(`
 - uri: blah://example
@@ -98,4 +113,9 @@ func TestLoadProvisionersFromDirectory(t *testing.T) {
 		uris[i] = prv.Uri()
 	}
 	assert.Equal(t, []string{"template://example-a", "template://example-b"}, uris)
+
+	p, sources, err := LoadProvisionersFromDirectoryWithSources(td, ".p.yaml")
+	require.NoError(t, err)
+	assert.Len(t, p, 2)
+	assert.Equal(t, []string{"00.p.yaml", "01.p.yaml"}, sources)
 }
@@ -0,0 +1,174 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/score-spec/score-go/framework"
+)
+
+// MatchConditions are optional conditions that a resource must meet, in addition to the type, class, and id, for a
+// provisioner to match it. All the conditions that are set must be met.
+type MatchConditions struct {
+	// Labels must all be set to the same values in the labels of the resource metadata.
+	Labels map[string]string `yaml:"labels,omitempty" json:",omitempty"`
+	// Annotations must all be set to the same values in the annotations of the resource metadata.
+	Annotations map[string]string `yaml:"annotations,omitempty" json:",omitempty"`
+	// Workload is a glob pattern, like "api-*", that the name of the source workload must match.
+	Workload string `yaml:"workload,omitempty" json:",omitempty"`
+	// Params must all be set in the resource params.
+	Params []string `yaml:"params,omitempty" json:",omitempty"`
+	// Namespace must be the namespace that the manifests are generated for.
+	Namespace string `yaml:"namespace,omitempty" json:",omitempty"`
+}
+
+// ConditionalProvisioner is implemented by provisioners that can set match conditions and a priority. Provisioners that
+// do not implement it have no conditions and a priority of 0.
+type ConditionalProvisioner interface {
+	// MatchConditions returns the conditions of the provisioner, or nil when it has none.
+	MatchConditions() *MatchConditions
+	// Priority returns the priority of the provisioner over the other provisioners that match the same resource.
+	Priority() int
+}
+
+// Validate checks that the workload pattern is a valid glob.
+func (c *MatchConditions) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if _, err := path.Match(c.Workload, ""); err != nil {
+		return fmt.Errorf("invalid workload pattern '%s': %w", c.Workload, err)
+	}
+	return nil
+}
+
+// Count returns the number of conditions that are set, each label, annotation, and param counts as a condition.
+func (c *MatchConditions) Count() int {
+	if c == nil {
+		return 0
+	}
+	count := len(c.Labels) + len(c.Annotations) + len(c.Params)
+	if c.Workload != "" {
+		count++
+	}
+	if c.Namespace != "" {
+		count++
+	}
+	return count
+}
+
+// String describes the conditions in a stable order, like "labels.tier=gold, workload=api-*".
+func (c *MatchConditions) String() string {
+	if c == nil {
+		return ""
+	}
+	parts := make([]string, 0, c.Count())
+	for _, k := range slices.Sorted(maps.Keys(c.Labels)) {
+		parts = append(parts, fmt.Sprintf("labels.%s=%s", k, c.Labels[k]))
+	}
+	for _, k := range slices.Sorted(maps.Keys(c.Annotations)) {
+		parts = append(parts, fmt.Sprintf("annotations.%s=%s", k, c.Annotations[k]))
+	}
+	if c.Workload != "" {
+		parts = append(parts, "workload="+c.Workload)
+	}
+	for _, p := range slices.Sorted(slices.Values(c.Params)) {
+		parts = append(parts, "params."+p)
+	}
+	if c.Namespace != "" {
+		parts = append(parts, "namespace="+c.Namespace)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// metadataMatches returns whether each of the values is set in the section of the resource metadata.
+func metadataMatches(metadata map[string]interface{}, section string, values map[string]string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	raw, _ := metadata[section].(map[string]interface{})
+	for k, v := range values {
+		if actual, ok := raw[k].(string); !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns whether the resource in the input meets all the conditions.
+func (c *MatchConditions) Matches(input *Input) bool {
+	if c == nil {
+		return true
+	}
+	if !metadataMatches(input.ResourceMetadata, "labels", c.Labels) || !metadataMatches(input.ResourceMetadata, "annotations", c.Annotations) {
+		return false
+	}
+	if c.Workload != "" {
+		if ok, _ := path.Match(c.Workload, input.SourceWorkload); !ok {
+			return false
+		}
+	}
+	for _, p := range c.Params {
+		if _, ok := input.ResourceParams[p]; !ok {
+			return false
+		}
+	}
+	return c.Namespace == "" || c.Namespace == input.Namespace
+}
+
+// conditionsAndPriority returns the match conditions and priority of the provisioner if it has any.
+func conditionsAndPriority(provisioner Provisioner) (*MatchConditions, int) {
+	if cp, ok := provisioner.(ConditionalProvisioner); ok {
+		return cp.MatchConditions(), cp.Priority()
+	}
+	return nil, 0
+}
+
+// ComparePrecedence returns a negative number when provisioner a takes precedence over provisioner b, a positive
+// number when b takes precedence over a, and 0 when they are equal. The provisioner with the higher priority takes
+// precedence, and then the provisioner with more match conditions. Provisioners that are equal keep their load order.
+func ComparePrecedence(a, b Provisioner) int {
+	aConditions, aPriority := conditionsAndPriority(a)
+	bConditions, bPriority := conditionsAndPriority(b)
+	if c := cmp.Compare(bPriority, aPriority); c != 0 {
+		return c
+	}
+	return cmp.Compare(bConditions.Count(), aConditions.Count())
+}
+
+// MatchProvisioner returns the provisioner that provisions the resource in the input. Of the provisioners that match
+// the type, class, and id of the resource and meet their match conditions, the one that takes precedence according to
+// ComparePrecedence wins, and otherwise the first one that was loaded.
+func MatchProvisioner(provisioners []Provisioner, input *Input) (Provisioner, bool) {
+	resUid := framework.ResourceUid(input.ResourceUid)
+	var out Provisioner
+	for _, provisioner := range provisioners {
+		if !provisioner.Match(resUid) {
+			continue
+		} else if conditions, _ := conditionsAndPriority(provisioner); !conditions.Matches(input) {
+			continue
+		}
+		if out == nil || ComparePrecedence(provisioner, out) < 0 {
+			out = provisioner
+		}
+	}
+	return out, out != nil
+}
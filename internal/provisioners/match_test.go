@@ -0,0 +1,127 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	"github.com/stretchr/testify/assert"
+)
+
+type conditionalProvisioner struct {
+	Provisioner
+	conditions *MatchConditions
+	priority   int
+}
+
+func (c *conditionalProvisioner) MatchConditions() *MatchConditions {
+	return c.conditions
+}
+
+func (c *conditionalProvisioner) Priority() int {
+	return c.priority
+}
+
+func TestMatchConditions(t *testing.T) {
+	input := &Input{
+		ResourceUid: "postgres.default#api-users.db",
+		ResourceMetadata: map[string]interface{}{
+			"labels":      map[string]interface{}{"tier": "gold"},
+			"annotations": map[string]interface{}{"team": "payments"},
+		},
+		ResourceParams: map[string]interface{}{"version": "16"},
+		SourceWorkload: "api-users",
+		Namespace:      "production",
+	}
+	for _, tc := range []struct {
+		name       string
+		conditions *MatchConditions
+		expected   bool
+	}{
+		{"nil", nil, true},
+		{"empty", &MatchConditions{}, true},
+		{"all", &MatchConditions{
+			Labels:      map[string]string{"tier": "gold"},
+			Annotations: map[string]string{"team": "payments"},
+			Workload:    "api-*",
+			Params:      []string{"version"},
+			Namespace:   "production",
+		}, true},
+		{"label value", &MatchConditions{Labels: map[string]string{"tier": "silver"}}, false},
+		{"missing annotation", &MatchConditions{Annotations: map[string]string{"owner": "me"}}, false},
+		{"workload", &MatchConditions{Workload: "web-*"}, false},
+		{"params", &MatchConditions{Params: []string{"version", "extensions"}}, false},
+		{"namespace", &MatchConditions{Namespace: "staging"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.conditions.Matches(input))
+		})
+	}
+}
+
+func TestMatchConditions_Validate(t *testing.T) {
+	assert.NoError(t, (*MatchConditions)(nil).Validate())
+	assert.EqualError(t, (&MatchConditions{Workload: "api-["}).Validate(), "invalid workload pattern 'api-[': syntax error in pattern")
+}
+
+func TestMatchConditions_String(t *testing.T) {
+	assert.Equal(t, "labels.a=1, labels.b=2, annotations.c=3, workload=api-*, params.x, params.y, namespace=ns", (&MatchConditions{
+		Labels:      map[string]string{"b": "2", "a": "1"},
+		Annotations: map[string]string{"c": "3"},
+		Workload:    "api-*",
+		Params:      []string{"y", "x"},
+		Namespace:   "ns",
+	}).String())
+}
+
+func TestMatchProvisioner_precedence(t *testing.T) {
+	input := &Input{
+		ResourceUid:      "postgres.default#api.db",
+		ResourceMetadata: map[string]interface{}{"labels": map[string]interface{}{"tier": "gold"}},
+		SourceWorkload:   "api",
+	}
+	newProvisioner := func(uri string, resType string, conditions *MatchConditions, priority int) Provisioner {
+		return &conditionalProvisioner{
+			Provisioner: NewEphemeralProvisioner(uri, framework.NewResourceUid("api", "db", resType, nil, nil), nil),
+			conditions:  conditions,
+			priority:    priority,
+		}
+	}
+	first := newProvisioner("ephemeral://first", "postgres", nil, 0)
+	second := newProvisioner("ephemeral://second", "postgres", nil, 0)
+	other := newProvisioner("ephemeral://other", "redis", nil, 10)
+	gold := newProvisioner("ephemeral://gold", "postgres", &MatchConditions{Labels: map[string]string{"tier": "gold"}}, 0)
+	silver := newProvisioner("ephemeral://silver", "postgres", &MatchConditions{Labels: map[string]string{"tier": "silver"}}, 5)
+	important := newProvisioner("ephemeral://important", "postgres", nil, 1)
+
+	for _, tc := range []struct {
+		name         string
+		provisioners []Provisioner
+		expected     Provisioner
+	}{
+		{"none", []Provisioner{other}, nil},
+		{"first loaded", []Provisioner{other, first, second}, first},
+		{"more conditions", []Provisioner{first, gold}, gold},
+		{"unmet conditions", []Provisioner{first, silver}, first},
+		{"higher priority", []Provisioner{first, gold, important}, important},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, ok := MatchProvisioner(tc.provisioners, input)
+			assert.Equal(t, tc.expected != nil, ok)
+			assert.Equal(t, tc.expected, p)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/score-spec/score-k8s/internal/validation"
+)
+
+// SchemaProvisioner is implemented by provisioners that declare JSON schemas for the params and outputs of the
+// resources they provision. The compiled schemas are compiled once when the provisioner is parsed and are used to
+// validate the params and outputs. A nil schema is not checked.
+type SchemaProvisioner interface {
+	ParamsSchema() map[string]interface{}
+	OutputsSchema() map[string]interface{}
+	CompiledParamsSchema() *jsonschema.Schema
+	CompiledOutputsSchema() *jsonschema.Schema
+}
+
+// CompileSchema compiles a JSON schema from a provisioner definition. This is used when parsing provisioners so that
+// invalid schemas are reported when the provisioners are loaded.
+func CompileSchema(name string, raw map[string]interface{}) (*jsonschema.Schema, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	rawSchema, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s as json: %w", name, err)
+	}
+	compiled, err := jsonschema.CompileString(name+".json", string(rawSchema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return compiled, nil
+}
+
+// validateAgainstSchema returns the problems found when validating the value against the compiled schema.
+func validateAgainstSchema(compiled *jsonschema.Schema, value map[string]interface{}) ([]string, error) {
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+	// the value is converted to plain json types since the schema validation does not accept other go types
+	rawValue, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value as json: %w", err)
+	}
+	var instance interface{}
+	dec := json.NewDecoder(bytes.NewReader(rawValue))
+	dec.UseNumber()
+	if err := dec.Decode(&instance); err != nil {
+		return nil, fmt.Errorf("failed to decode value as json: %w", err)
+	}
+	if err := compiled.Validate(instance); err != nil {
+		return validation.FlattenSchemaError(err), nil
+	}
+	return nil, nil
+}
+
+// applySchemaDefaults returns a copy of the value with the default of each property in the schema set when the value
+// does not set the property. Nested object properties are filled in the same way.
+func applySchemaDefaults(schema map[string]interface{}, value map[string]interface{}) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if len(properties) == 0 {
+		return value
+	}
+	out := maps.Clone(value)
+	if out == nil {
+		out = make(map[string]interface{})
+	}
+	for name, rawProperty := range properties {
+		property, _ := rawProperty.(map[string]interface{})
+		if property == nil {
+			continue
+		}
+		if existing, ok := out[name]; ok {
+			if nested, ok := existing.(map[string]interface{}); ok {
+				out[name] = applySchemaDefaults(property, nested)
+			}
+		} else if d, ok := property["default"]; ok {
+			out[name] = d
+		}
+	}
+	return out
+}
+
+// checkParamsSchema applies the defaults of the params schema of the provisioner to the params and validates the
+// result. The params are returned unchanged when the provisioner has no params schema.
+func checkParamsSchema(resUid string, provisioner Provisioner, params map[string]interface{}) (map[string]interface{}, error) {
+	sp, ok := provisioner.(SchemaProvisioner)
+	if !ok || sp.ParamsSchema() == nil {
+		return params, nil
+	}
+	params = applySchemaDefaults(sp.ParamsSchema(), params)
+	if !isStrict(provisioner) || sp.CompiledParamsSchema() == nil {
+		return params, nil
+	}
+	problems, err := validateAgainstSchema(sp.CompiledParamsSchema(), params)
+	if err != nil {
+		return nil, fmt.Errorf("resource '%s': provisioner '%s': %w", resUid, provisioner.Uri(), err)
+	} else if len(problems) > 0 {
+		return nil, fmt.Errorf("resource '%s': params do not match the params schema of provisioner '%s': %s", resUid, provisioner.Uri(), strings.Join(problems, ", "))
+	}
+	return params, nil
+}
+
+// checkOutputsSchema validates the outputs against the outputs schema of the provisioner.
+func checkOutputsSchema(resUid string, provisioner Provisioner, outputs map[string]interface{}) error {
+	sp, ok := provisioner.(SchemaProvisioner)
+	if !ok || sp.CompiledOutputsSchema() == nil || !isStrict(provisioner) {
+		return nil
+	}
+	problems, err := validateAgainstSchema(sp.CompiledOutputsSchema(), outputs)
+	if err != nil {
+		return fmt.Errorf("resource '%s': provisioner '%s': %w", resUid, provisioner.Uri(), err)
+	} else if len(problems) > 0 {
+		return fmt.Errorf("resource '%s': outputs do not match the outputs schema of provisioner '%s': %s", resUid, provisioner.Uri(), strings.Join(problems, ", "))
+	}
+	return nil
+}
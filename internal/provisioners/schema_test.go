@@ -0,0 +1,117 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaProvisioner struct {
+	Provisioner
+	CommonFields
+}
+
+func TestCompileSchema(t *testing.T) {
+	compiled, err := CompileSchema("params_schema", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, compiled)
+
+	_, err = CompileSchema("params_schema", map[string]interface{}{"type": 5})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid params_schema: ")
+}
+
+func TestCommonFieldsValidate(t *testing.T) {
+	c := &CommonFields{ResOutputsSchema: map[string]interface{}{"type": "object"}}
+	require.NoError(t, c.Validate())
+	assert.Nil(t, c.CompiledParamsSchema())
+	assert.NotNil(t, c.CompiledOutputsSchema())
+
+	err := (&CommonFields{ResOutputsSchema: map[string]interface{}{"type": 5}}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid outputs_schema: ")
+}
+
+func TestApplySchemaDefaults(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"port":    map[string]interface{}{"type": "integer", "default": 80},
+			"version": map[string]interface{}{"type": "string"},
+			"tls": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"enabled": map[string]interface{}{"default": true}},
+			},
+		},
+	}
+	params := map[string]interface{}{"port": 8080, "tls": map[string]interface{}{}}
+	assert.Equal(t, map[string]interface{}{
+		"port": 8080,
+		"tls":  map[string]interface{}{"enabled": true},
+	}, applySchemaDefaults(schema, params))
+	assert.Equal(t, map[string]interface{}{"port": 8080, "tls": map[string]interface{}{}}, params)
+	assert.Equal(t, map[string]interface{}{"port": 80}, applySchemaDefaults(schema, nil))
+}
+
+func TestProvisionResources_schemas(t *testing.T) {
+	startState := buildParallelTestState(t)
+	resUid := framework.ResourceUid("t.default#w.dependent")
+	var received map[string]interface{}
+	newProvisioner := func(paramsSchema, outputsSchema map[string]interface{}) Provisioner {
+		p := &schemaProvisioner{
+			Provisioner: NewEphemeralProvisioner("ephemeral://schema", resUid, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+				received = input.ResourceParams
+				return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"port": "eighty"}}, nil
+			}),
+			CommonFields: CommonFields{ResParamsSchema: paramsSchema, ResOutputsSchema: outputsSchema},
+		}
+		require.NoError(t, p.Validate())
+		return p
+	}
+	others := forEachResource(startState, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"value": "x"}}, nil
+	})
+
+	t.Run("defaults are applied", func(t *testing.T) {
+		_, err := ProvisionResources(context.Background(), startState, append([]Provisioner{newProvisioner(map[string]interface{}{
+			"type":       "object",
+			"required":   []interface{}{"from", "version"},
+			"properties": map[string]interface{}{"version": map[string]interface{}{"enum": []interface{}{"15", "16"}, "default": "16"}},
+		}, nil)}, others...), "")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"from": "x", "version": "16"}, received)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := ProvisionResources(context.Background(), startState, append([]Provisioner{newProvisioner(map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"from": map[string]interface{}{"type": "integer"}},
+		}, nil)}, others...), "")
+		assert.EqualError(t, err, "resource 't.default#w.dependent': params do not match the params schema of provisioner 'ephemeral://schema': from: expected integer, but got string")
+	})
+
+	t.Run("invalid outputs", func(t *testing.T) {
+		_, err := ProvisionResources(context.Background(), startState, append([]Provisioner{newProvisioner(nil, map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"port": map[string]interface{}{"type": "integer"}},
+		})}, others...), "")
+		assert.EqualError(t, err, "resource 't.default#w.dependent': outputs do not match the outputs schema of provisioner 'ephemeral://schema': port: expected integer, but got string")
+	})
+}
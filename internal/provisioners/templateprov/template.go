@@ -17,11 +17,13 @@ package templateprov
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"slices"
 	"strings"
 	"text/template"
+	"text/template/parse"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/go-viper/mapstructure/v2"
@@ -55,11 +57,27 @@ type Provisioner struct {
 
 	ManifestsTemplate string `yaml:"manifests,omitempty"`
 
+	// RbacRulesTemplate generates the Kubernetes RBAC policy rules that the workloads using the resource need, like
+	// read access to a ConfigMap, based on the init and current state. When rules are returned, the ServiceAccount
+	// token is mounted into the pods of these workloads by default.
+	RbacRulesTemplate string `yaml:"rbac_rules,omitempty"`
+
+	// DeprovisionTemplate generates modifications to the shared state when the resource is no longer referenced by any
+	// workload, based on the last state of the resource.
+	DeprovisionTemplate string `yaml:"deprovision,omitempty"`
+
 	// SupportedParams is a list of parameters that the provisioner expects to be passed in.
 	SupportedParams []string `yaml:"supported_params,omitempty"`
 
 	// ExpectedOutputs is a list of expected outputs that the provisioner should return.
 	ExpectedOutputs []string `yaml:"expected_outputs,omitempty"`
+
+	// CommonFields holds the params and outputs schemas, strict, match, and priority fields.
+	provisioners.CommonFields `yaml:",inline"`
+
+	// ResReadsSharedState overrides whether the templates read the shared state. When it is not set, this is detected
+	// from the templates.
+	ResReadsSharedState *bool `yaml:"reads_shared_state,omitempty"`
 }
 
 func Parse(raw map[string]interface{}) (*Provisioner, error) {
@@ -75,6 +93,9 @@ func Parse(raw map[string]interface{}) (*Provisioner, error) {
 	} else if p.ResType == "" {
 		return nil, fmt.Errorf("type not set")
 	}
+	if err := p.CommonFields.Validate(); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
 
@@ -82,6 +103,85 @@ func (p *Provisioner) Uri() string {
 	return p.ProvisionerUri
 }
 
+// ReadsSharedState returns the reads_shared_state field when it is set, otherwise whether any of the provisioning
+// templates may read the shared state.
+func (p *Provisioner) ReadsSharedState() bool {
+	if p.ResReadsSharedState != nil {
+		return *p.ResReadsSharedState
+	}
+	for _, tpl := range []string{p.InitTemplate, p.StateTemplate, p.SharedStateTemplate, p.OutputsTemplate, p.ManifestsTemplate, p.RbacRulesTemplate} {
+		if templateReadsSharedState(tpl) {
+			return true
+		}
+	}
+	return false
+}
+
+// templateReadsSharedState walks the parsed template and returns whether it may read the Shared field of its input.
+// Since the whole input can be passed to a function or assigned to a variable, any use of the root input other than
+// accessing one of its fields counts as reading the shared state. Templates that fail to parse are assumed to read it.
+func templateReadsSharedState(raw string) bool {
+	prepared, err := parseTemplate(raw)
+	if err != nil {
+		return true
+	}
+	for _, t := range prepared.Templates() {
+		if t.Tree != nil && nodeReadsSharedState(t.Tree.Root, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeReadsSharedState returns whether the node may read the shared state. The dot refers to the root input when
+// dotIsRoot is true, it does not within the body of range and with actions.
+func nodeReadsSharedState(node parse.Node, dotIsRoot bool) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if nodeReadsSharedState(child, dotIsRoot) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeReadsSharedState(n.Pipe, dotIsRoot)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				if nodeReadsSharedState(arg, dotIsRoot) {
+					return true
+				}
+			}
+		}
+	case *parse.IfNode:
+		return nodeReadsSharedState(n.Pipe, dotIsRoot) || nodeReadsSharedState(n.List, dotIsRoot) || nodeReadsSharedState(n.ElseList, dotIsRoot)
+	case *parse.RangeNode:
+		return nodeReadsSharedState(n.Pipe, dotIsRoot) || nodeReadsSharedState(n.List, false) || nodeReadsSharedState(n.ElseList, dotIsRoot)
+	case *parse.WithNode:
+		return nodeReadsSharedState(n.Pipe, dotIsRoot) || nodeReadsSharedState(n.List, false) || nodeReadsSharedState(n.ElseList, dotIsRoot)
+	case *parse.TemplateNode:
+		return nodeReadsSharedState(n.Pipe, dotIsRoot)
+	case *parse.DotNode:
+		return dotIsRoot
+	case *parse.FieldNode:
+		return dotIsRoot && n.Ident[0] == "Shared"
+	case *parse.VariableNode:
+		return n.Ident[0] == "$" && (len(n.Ident) == 1 || n.Ident[1] == "Shared")
+	case *parse.ChainNode:
+		if len(n.Field) > 0 && n.Field[0] == "Shared" {
+			return true
+		}
+		return nodeReadsSharedState(n.Node, dotIsRoot)
+	}
+	return false
+}
+
 func (p *Provisioner) Match(resUid framework.ResourceUid) bool {
 	if resUid.Type() != p.ResType {
 		return false
@@ -128,15 +228,19 @@ func (p *Provisioner) Outputs() []string {
 	return outputs
 }
 
+func parseTemplate(raw string) (*template.Template, error) {
+	return template.New("").
+		Funcs(sprig.FuncMap()).
+		Funcs(template.FuncMap{"encodeSecretRef": util.EncodeSecretReference}).
+		Parse(raw)
+}
+
 func renderTemplateAndDecode(raw string, data interface{}, out interface{}) error {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		return nil
 	}
-	prepared, err := template.New("").
-		Funcs(sprig.FuncMap()).
-		Funcs(template.FuncMap{"encodeSecretRef": util.EncodeSecretReference}).
-		Parse(raw)
+	prepared, err := parseTemplate(raw)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
@@ -243,7 +347,49 @@ func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input)
 		}
 	}
 
+	var rawRbacRules []interface{}
+	if err := renderTemplateAndDecode(p.RbacRulesTemplate, &data, &rawRbacRules); err != nil {
+		return nil, fmt.Errorf("rbac_rules template failed: %w", err)
+	} else if len(rawRbacRules) > 0 {
+		raw, _ := json.Marshal(rawRbacRules)
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&out.RbacRules); err != nil {
+			return nil, fmt.Errorf("rbac_rules template failed: failed to decode output: %w", err)
+		}
+	}
+
+	return out, nil
+}
+
+// Deprovision evaluates the deprovision template, if any, to generate the final shared state modifications for a
+// resource that is no longer referenced.
+func (p *Provisioner) Deprovision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	if p.DeprovisionTemplate == "" {
+		return nil, nil
+	}
+	data := Data{
+		Guid:             input.ResourceGuid,
+		Uid:              input.ResourceUid,
+		Type:             input.ResourceType,
+		Class:            input.ResourceClass,
+		Id:               input.ResourceId,
+		Metadata:         input.ResourceMetadata,
+		State:            input.ResourceState,
+		Shared:           input.SharedState,
+		SourceWorkload:   input.SourceWorkload,
+		WorkloadServices: input.WorkloadServices,
+		Namespace:        input.Namespace,
+	}
+	out := &provisioners.ProvisionOutput{SharedState: make(map[string]interface{})}
+	if err := renderTemplateAndDecode(p.DeprovisionTemplate, &data, &out.SharedState); err != nil {
+		return nil, fmt.Errorf("deprovision template failed: %w", err)
+	}
 	return out, nil
 }
 
 var _ provisioners.Provisioner = (*Provisioner)(nil)
+var _ provisioners.SchemaProvisioner = (*Provisioner)(nil)
+var _ provisioners.Deprovisioner = (*Provisioner)(nil)
+var _ provisioners.ConditionalProvisioner = (*Provisioner)(nil)
+var _ provisioners.SharedStateProvisioner = (*Provisioner)(nil)
@@ -21,6 +21,7 @@ import (
 	"github.com/score-spec/score-go/framework"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	rbacV1 "k8s.io/api/rbac/v1"
 
 	"github.com/score-spec/score-k8s/internal/provisioners"
 )
@@ -83,3 +84,131 @@ c: {{ .Shared.c }}
 	assert.Equal(t, "(any)", p.Class())
 	assert.Equal(t, resUid.Type(), p.Type())
 }
+
+func TestProvision_rbacRules(t *testing.T) {
+	resUid := framework.NewResourceUid("w", "r", "thing", nil, nil)
+	p, err := Parse(map[string]interface{}{
+		"uri":   "template://example",
+		"type":  resUid.Type(),
+		"state": `name: {{ .Type }}-config`,
+		"rbac_rules": `
+- apiGroups: [""]
+  resources: [configmaps]
+  resourceNames: [{{ .State.name }}]
+  verbs: [get, watch]
+`,
+	})
+	require.NoError(t, err)
+	out, err := p.Provision(context.Background(), &provisioners.Input{ResourceUid: string(resUid), ResourceType: resUid.Type()})
+	require.NoError(t, err)
+	assert.Equal(t, []rbacV1.PolicyRule{{
+		APIGroups:     []string{""},
+		Resources:     []string{"configmaps"},
+		ResourceNames: []string{"thing-config"},
+		Verbs:         []string{"get", "watch"},
+	}}, out.RbacRules)
+
+	p, err = Parse(map[string]interface{}{
+		"uri":        "template://example",
+		"type":       resUid.Type(),
+		"rbac_rules": `[{"resources": ["configmaps"], "verb": ["get"]}]`,
+	})
+	require.NoError(t, err)
+	_, err = p.Provision(context.Background(), &provisioners.Input{ResourceUid: string(resUid)})
+	assert.EqualError(t, err, "rbac_rules template failed: failed to decode output: json: unknown field \"verb\"")
+}
+
+func TestDeprovision(t *testing.T) {
+	resUid := framework.NewResourceUid("w", "r", "thing", nil, nil)
+	p, err := Parse(map[string]interface{}{
+		"uri":  "template://example",
+		"type": resUid.Type(),
+		"deprovision": `
+{{ .State.key }}: null
+count: {{ sub .Shared.count 1 }}
+`,
+	})
+	require.NoError(t, err)
+	out, err := p.Deprovision(context.Background(), &provisioners.Input{
+		ResourceUid:   string(resUid),
+		ResourceState: map[string]interface{}{"key": "instance"},
+		SharedState:   map[string]interface{}{"instance": "thing", "count": 2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"instance": nil, "count": 1}, out.SharedState)
+
+	p, err = Parse(map[string]interface{}{"uri": "template://example", "type": resUid.Type()})
+	require.NoError(t, err)
+	out, err = p.Deprovision(context.Background(), &provisioners.Input{ResourceUid: string(resUid)})
+	require.NoError(t, err)
+	assert.Nil(t, out)
+}
+
+func TestParse_strict(t *testing.T) {
+	p, err := Parse(map[string]interface{}{"uri": "template://example", "type": "thing"})
+	require.NoError(t, err)
+	assert.True(t, p.Strict())
+	p, err = Parse(map[string]interface{}{"uri": "template://example", "type": "thing", "strict": false})
+	require.NoError(t, err)
+	assert.False(t, p.Strict())
+}
+
+func TestReadsSharedState(t *testing.T) {
+	p, err := Parse(map[string]interface{}{"uri": "template://example", "type": "thing", "state": "a: {{ .Init.a }}", "shared": "b: 1"})
+	require.NoError(t, err)
+	assert.False(t, p.ReadsSharedState())
+	p, err = Parse(map[string]interface{}{"uri": "template://example", "type": "thing", "outputs": "b: {{ .Shared.b }}"})
+	require.NoError(t, err)
+	assert.True(t, p.ReadsSharedState())
+
+	t.Run("explicit", func(t *testing.T) {
+		p, err := Parse(map[string]interface{}{"uri": "template://example", "type": "thing", "outputs": "b: {{ .Shared.b }}", "reads_shared_state": false})
+		require.NoError(t, err)
+		assert.False(t, p.ReadsSharedState())
+		p, err = Parse(map[string]interface{}{"uri": "template://example", "type": "thing", "outputs": "b: 1", "reads_shared_state": true})
+		require.NoError(t, err)
+		assert.True(t, p.ReadsSharedState())
+	})
+
+	for _, tc := range []struct {
+		name     string
+		template string
+		expected bool
+	}{
+		{"text", "# Shared with other workloads\nSharedKey: {{ .State.Shared }}", false},
+		{"comment", "{{/* .Shared */}}a: 1", false},
+		{"field", "a: {{ .Shared.a | default 1 }}", true},
+		{"root variable", "a: {{ $.Shared.a }}", true},
+		{"root variable in range", "{{ range .Params.items }}a: {{ $.Shared.a }}{{ end }}", true},
+		{"index", `a: {{ index . "Shared" "a" }}`, true},
+		{"index of root variable", `a: {{ index $ "Shared" "a" }}`, true},
+		{"assigned variable", `{{ $in := . }}a: {{ $in.Shared.a }}`, true},
+		{"passed to function", `a: {{ toJson . }}`, true},
+		{"field in condition", `{{ if .Shared.a }}a: 1{{ end }}`, true},
+		{"dot in range", "a: [{{ range .Params.items }}{{ . }},{{ end }}]", false},
+		{"field of range item", "a: [{{ range .Params.items }}{{ .Shared }},{{ end }}]", false},
+		{"dot in with", "{{ with .State.a }}a: {{ . }}{{ end }}", false},
+		{"dot in with else", "{{ with .State.a }}a: 1{{ else }}a: {{ toJson . }}{{ end }}", true},
+		{"template call", `{{ define "x" }}{{ .Shared.a }}{{ end }}a: {{ template "x" .State }}`, true},
+		{"invalid template", "{{ .Shared", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, templateReadsSharedState(tc.template))
+		})
+	}
+}
+
+func TestParse_match(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri": "template://example", "type": "thing", "priority": 3,
+		"match": map[string]interface{}{"labels": map[string]interface{}{"tier": "gold"}, "workload": "api-*"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, p.Priority())
+	assert.Equal(t, &provisioners.MatchConditions{Labels: map[string]string{"tier": "gold"}, Workload: "api-*"}, p.MatchConditions())
+
+	_, err = Parse(map[string]interface{}{"uri": "template://example", "type": "thing", "match": map[string]interface{}{"workload": "["}})
+	assert.EqualError(t, err, "invalid match: invalid workload pattern '[': syntax error in pattern")
+	_, err = Parse(map[string]interface{}{"uri": "template://example", "type": "thing", "match": map[string]interface{}{"workloads": "*"}})
+	assert.Error(t, err)
+}
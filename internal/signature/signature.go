@@ -0,0 +1,158 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signature verifies the detached ed25519 signatures of provisioner files against a set of trusted public keys.
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+const (
+	// Suffix is added to the name of a file to find its detached signature.
+	Suffix = ".sig"
+	// ArtifactType is the artifact type of OCI referrers that contain the signatures of the files in their subject. Each
+	// layer is a signature and is titled with the name of the signed file followed by the Suffix.
+	ArtifactType = "application/vnd.score.signature.v1"
+)
+
+// TrustedKey is a public key that signatures are verified against.
+type TrustedKey struct {
+	// Name is the file that the key was loaded from.
+	Name string
+	Key  ed25519.PublicKey
+}
+
+// ParsePublicKey parses a PEM encoded PKIX ed25519 public key, as written by `openssl pkey -pubout`.
+func ParsePublicKey(raw []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("expected a PEM encoded PUBLIC KEY")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T, only ed25519 keys are supported", key)
+	}
+	return edKey, nil
+}
+
+// LoadTrustedKeys loads the public keys from the files and from each file in the directory. The directory does not need
+// to exist.
+func LoadTrustedKeys(files []string, directory string) ([]TrustedKey, error) {
+	files = slices.Clone(files)
+	if entries, err := os.ReadDir(directory); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read trusted keys directory: %w", err)
+	} else {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(directory, entry.Name()))
+			}
+		}
+	}
+	out := make([]TrustedKey, 0, len(files))
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted key: %w", err)
+		}
+		key, err := ParsePublicKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("trusted key '%s': %w", f, err)
+		}
+		out = append(out, TrustedKey{Name: f, Key: key})
+	}
+	return out, nil
+}
+
+// decodeSignature decodes a base64 encoded signature, or returns the raw signature if it is not base64 encoded.
+func decodeSignature(raw []byte) []byte {
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw))); err == nil && len(decoded) == ed25519.SignatureSize {
+		return decoded
+	}
+	return raw
+}
+
+// Verify checks that any of the signatures is a valid signature of the content by any of the trusted keys and returns
+// the name of the key.
+func Verify(keys []TrustedKey, content []byte, signatures [][]byte) (string, error) {
+	if len(signatures) == 0 {
+		return "", fmt.Errorf("no signature found")
+	}
+	for _, raw := range signatures {
+		sig := decodeSignature(raw)
+		for _, k := range keys {
+			if len(sig) == ed25519.SignatureSize && ed25519.Verify(k.Key, content, sig) {
+				return k.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("signature is not valid for any of the %d trusted keys", len(keys))
+}
+
+// FetchReferrerSignatures returns the signatures of the file from the referrers of the manifest that the reference
+// resolves to. When the file name is empty, the manifest is expected to contain a single file and the only signature
+// in each referrer is used.
+func FetchReferrerSignatures(ctx context.Context, target oras.ReadOnlyGraphTarget, reference string, file string) ([][]byte, error) {
+	desc, err := target.Resolve(ctx, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", reference, err)
+	}
+	referrers, err := registry.Referrers(ctx, target, desc, ArtifactType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers: %w", err)
+	}
+	var out [][]byte
+	for _, referrer := range referrers {
+		rawManifest, err := content.FetchAll(ctx, target, referrer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch referrer %s: %w", referrer.Digest, err)
+		}
+		var manifest ocispec.Manifest
+		if err := json.NewDecoder(bytes.NewReader(rawManifest)).Decode(&manifest); err != nil {
+			return nil, fmt.Errorf("failed to decode referrer %s: %w", referrer.Digest, err)
+		}
+		for _, layer := range manifest.Layers {
+			title := layer.Annotations[ocispec.AnnotationTitle]
+			if (file == "" && len(manifest.Layers) == 1) || (file != "" && title == file+Suffix) {
+				sig, err := content.FetchAll(ctx, target, layer)
+				if err != nil {
+					return nil, fmt.Errorf("failed to fetch signature %s: %w", layer.Digest, err)
+				}
+				out = append(out, sig)
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func generateKey(t *testing.T) (ed25519.PrivateKey, []byte) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	raw, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return priv, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: raw})
+}
+
+func TestLoadTrustedKeys(t *testing.T) {
+	td := t.TempDir()
+	_, pemOne := generateKey(t)
+	_, pemTwo := generateKey(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "one.pem"), pemOne, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(td, "keys"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "keys", "two.pem"), pemTwo, 0600))
+
+	t.Run("nominal", func(t *testing.T) {
+		keys, err := LoadTrustedKeys([]string{filepath.Join(td, "one.pem")}, filepath.Join(td, "keys"))
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, filepath.Join(td, "one.pem"), keys[0].Name)
+		assert.Equal(t, filepath.Join(td, "keys", "two.pem"), keys[1].Name)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		keys, err := LoadTrustedKeys(nil, filepath.Join(td, "missing"))
+		require.NoError(t, err)
+		assert.Len(t, keys, 0)
+	})
+
+	t.Run("not pem", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(td, "bad.pem"), []byte("nope"), 0600))
+		_, err := LoadTrustedKeys([]string{filepath.Join(td, "bad.pem")}, "")
+		assert.EqualError(t, err, "trusted key '"+filepath.Join(td, "bad.pem")+"': expected a PEM encoded PUBLIC KEY")
+	})
+
+	t.Run("not ed25519", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		raw, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(td, "ecdsa.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: raw}), 0600))
+		_, err = LoadTrustedKeys([]string{filepath.Join(td, "ecdsa.pem")}, "")
+		assert.EqualError(t, err, "trusted key '"+filepath.Join(td, "ecdsa.pem")+"': unsupported key type *ecdsa.PublicKey, only ed25519 keys are supported")
+	})
+}
+
+func TestVerify(t *testing.T) {
+	privOne, pemOne := generateKey(t)
+	privTwo, _ := generateKey(t)
+	pubOne, err := ParsePublicKey(pemOne)
+	require.NoError(t, err)
+	keys := []TrustedKey{{Name: "one", Key: pubOne}}
+	content := []byte("- uri: template://example\n  type: thing\n")
+
+	t.Run("base64", func(t *testing.T) {
+		name, err := Verify(keys, content, [][]byte{[]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privOne, content)) + "\n")})
+		require.NoError(t, err)
+		assert.Equal(t, "one", name)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		name, err := Verify(keys, content, [][]byte{ed25519.Sign(privOne, content)})
+		require.NoError(t, err)
+		assert.Equal(t, "one", name)
+	})
+
+	t.Run("any of multiple", func(t *testing.T) {
+		_, err := Verify(keys, content, [][]byte{ed25519.Sign(privTwo, content), ed25519.Sign(privOne, content)})
+		require.NoError(t, err)
+	})
+
+	t.Run("untrusted key", func(t *testing.T) {
+		_, err := Verify(keys, content, [][]byte{ed25519.Sign(privTwo, content)})
+		assert.EqualError(t, err, "signature is not valid for any of the 1 trusted keys")
+	})
+
+	t.Run("modified content", func(t *testing.T) {
+		_, err := Verify(keys, append(content, '#'), [][]byte{ed25519.Sign(privOne, content)})
+		assert.EqualError(t, err, "signature is not valid for any of the 1 trusted keys")
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		_, err := Verify(keys, content, nil)
+		assert.EqualError(t, err, "no signature found")
+	})
+}
+
+func TestFetchReferrerSignatures(t *testing.T) {
+	ctx := context.Background()
+	store, err := oci.New(t.TempDir())
+	require.NoError(t, err)
+	priv, _ := generateKey(t)
+
+	push := func(mediaType string, raw []byte, title string) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, raw)
+		desc.Annotations = map[string]string{ocispec.AnnotationTitle: title}
+		require.NoError(t, store.Push(ctx, desc, bytes.NewReader(raw)))
+		return desc
+	}
+	fileContent := []byte("- uri: template://example\n  type: thing\n")
+	fileDesc := push("application/yaml", fileContent, "p.yaml")
+	subject, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.score.provisioners.v1", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{fileDesc},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.Tag(ctx, subject, "v1"))
+
+	t.Run("unsigned", func(t *testing.T) {
+		sigs, err := FetchReferrerSignatures(ctx, store, "v1", "p.yaml")
+		require.NoError(t, err)
+		assert.Len(t, sigs, 0)
+	})
+
+	sig := ed25519.Sign(priv, fileContent)
+	sigDesc := push("application/octet-stream", sig, "p.yaml"+Suffix)
+	_, err = oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, ArtifactType, oras.PackManifestOptions{
+		Subject: &subject,
+		Layers:  []ocispec.Descriptor{sigDesc},
+	})
+	require.NoError(t, err)
+
+	t.Run("by file", func(t *testing.T) {
+		sigs, err := FetchReferrerSignatures(ctx, store, "v1", "p.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{sig}, sigs)
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		sigs, err := FetchReferrerSignatures(ctx, store, "v1", "")
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{sig}, sigs)
+	})
+
+	t.Run("other file", func(t *testing.T) {
+		sigs, err := FetchReferrerSignatures(ctx, store, "v1", "other.yaml")
+		require.NoError(t, err)
+		assert.Len(t, sigs, 0)
+	})
+
+	t.Run("unknown reference", func(t *testing.T) {
+		_, err := FetchReferrerSignatures(ctx, store, "v2", "p.yaml")
+		assert.ErrorContains(t, err, "failed to resolve 'v2': ")
+	})
+}
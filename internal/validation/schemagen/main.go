@@ -0,0 +1,451 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command schemagen generates the OpenAPI definitions that the validation package bundles for each Kubernetes release.
+// Like the upstream openapi-gen, it reads the Go source of the k8s.io/api and k8s.io/apimachinery modules of the
+// release: fields are required unless they have the +optional comment tag or omitempty json tag, and types with custom
+// json encodings, like IntOrString and Quantity, are mapped to the values they accept. Unlike the upstream spec, objects
+// do not allow additional properties so that unknown fields are reported.
+//
+// Usage:
+//
+//	go run ./schemagen -out schemas 1.34=v0.34.1 1.36=v0.36.2
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	apiModule          = "k8s.io/api"
+	apimachineryModule = "k8s.io/apimachinery"
+	metaV1Package      = apimachineryModule + "/pkg/apis/meta/v1"
+)
+
+// apiGroups are the packages whose kinds are bundled, along with their api group.
+var apiGroups = []struct {
+	path  string
+	group string
+}{
+	{"core/v1", ""},
+	{"apps/v1", "apps"},
+	{"batch/v1", "batch"},
+	{"autoscaling/v1", "autoscaling"},
+	{"autoscaling/v2", "autoscaling"},
+	{"networking/v1", "networking.k8s.io"},
+	{"policy/v1", "policy"},
+	{"rbac/v1", "rbac.authorization.k8s.io"},
+	{"scheduling/v1", "scheduling.k8s.io"},
+	{"storage/v1", "storage.k8s.io"},
+}
+
+var intOrString = map[string]interface{}{
+	"x-kubernetes-int-or-string": true,
+	"anyOf":                      []interface{}{map[string]interface{}{"type": "integer"}, map[string]interface{}{"type": "string"}},
+}
+
+// customTypes are the types with a custom json encoding, by package path and type name.
+var customTypes = map[string]map[string]interface{}{
+	apimachineryModule + "/pkg/util/intstr.IntOrString": intOrString,
+	apimachineryModule + "/pkg/api/resource.Quantity": {
+		"anyOf": []interface{}{map[string]interface{}{"type": "number"}, map[string]interface{}{"type": "string"}},
+	},
+	metaV1Package + ".Time":                          {"type": "string", "format": "date-time"},
+	metaV1Package + ".MicroTime":                     {"type": "string", "format": "date-time"},
+	metaV1Package + ".Duration":                      {"type": "string"},
+	metaV1Package + ".FieldsV1":                      {"type": "object"},
+	apimachineryModule + "/pkg/runtime.RawExtension": {"type": "object"},
+}
+
+func main() {
+	out := flag.String("out", "schemas", "The directory to write the definitions of each release to")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		log.Fatal("expected one or more releases like 1.36=v0.36.2")
+	}
+	for _, arg := range flag.Args() {
+		release, moduleVersion, ok := strings.Cut(arg, "=")
+		if !ok {
+			log.Fatalf("invalid release '%s', expected a release like 1.36=v0.36.2", arg)
+		}
+		if err := generate(release, moduleVersion, *out); err != nil {
+			log.Fatalf("release %s: %v", release, err)
+		}
+	}
+}
+
+// moduleDir downloads the module into the module cache and returns its directory.
+func moduleDir(module, version string) (string, error) {
+	raw, err := exec.Command("go", "mod", "download", "-json", module+"@"+version).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s@%s: %w", module, version, err)
+	}
+	var info struct{ Dir string }
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return "", fmt.Errorf("failed to decode download info of %s@%s: %w", module, version, err)
+	}
+	return info.Dir, nil
+}
+
+func generate(release, moduleVersion, outDir string) error {
+	g := &generator{
+		moduleDirs:  make(map[string]string),
+		packages:    make(map[string]*sourcePackage),
+		definitions: make(map[string]interface{}),
+	}
+	for _, module := range []string{apiModule, apimachineryModule} {
+		dir, err := moduleDir(module, moduleVersion)
+		if err != nil {
+			return err
+		}
+		g.moduleDirs[module] = dir
+	}
+	for _, group := range apiGroups {
+		pkg, err := g.loadPackage(apiModule + "/" + group.path)
+		if err != nil {
+			return err
+		}
+		for name, spec := range pkg.types {
+			if !isKind(spec) {
+				continue
+			}
+			if _, err := g.namedSchema(pkg, name); err != nil {
+				return err
+			}
+			g.definitions[definitionName(pkg.path, name)].(map[string]interface{})["x-kubernetes-group-version-kind"] = []interface{}{
+				map[string]interface{}{"group": group.group, "version": path.Base(group.path), "kind": name},
+			}
+		}
+	}
+
+	raw, err := json.MarshalIndent(map[string]interface{}{
+		"swagger":     "2.0",
+		"info":        map[string]interface{}{"title": "Kubernetes", "version": "v" + release},
+		"paths":       map[string]interface{}{},
+		"definitions": g.definitions,
+	}, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "v"+release+".json"), append(raw, '\n'), 0644)
+}
+
+type sourcePackage struct {
+	path    string
+	imports map[*ast.File]map[string]string
+	types   map[string]*typeSpec
+}
+
+type typeSpec struct {
+	spec *ast.TypeSpec
+	file *ast.File
+	doc  string
+}
+
+type generator struct {
+	moduleDirs  map[string]string
+	packages    map[string]*sourcePackage
+	definitions map[string]interface{}
+}
+
+// loadPackage parses the non-test Go files of the package with the import path.
+func (g *generator) loadPackage(importPath string) (*sourcePackage, error) {
+	if pkg, ok := g.packages[importPath]; ok {
+		return pkg, nil
+	}
+	var dir string
+	for module, moduleDir := range g.moduleDirs {
+		if rest, ok := strings.CutPrefix(importPath, module+"/"); ok {
+			dir = filepath.Join(moduleDir, rest)
+		}
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("package '%s' is not in a bundled module", importPath)
+	}
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse package '%s': %w", importPath, err)
+	}
+	pkg := &sourcePackage{path: importPath, imports: make(map[*ast.File]map[string]string), types: make(map[string]*typeSpec)}
+	for _, astPkg := range pkgs {
+		for _, file := range astPkg.Files {
+			imports := make(map[string]string)
+			for _, spec := range file.Imports {
+				p, _ := strconv.Unquote(spec.Path.Value)
+				name := path.Base(p)
+				if spec.Name != nil {
+					name = spec.Name.Name
+				}
+				imports[name] = p
+			}
+			pkg.imports[file] = imports
+			previousEnd := file.Name.End()
+			for _, decl := range file.Decls {
+				genDecl, ok := decl.(*ast.GenDecl)
+				if ok && genDecl.Tok == token.TYPE {
+					for _, spec := range genDecl.Specs {
+						ts := spec.(*ast.TypeSpec)
+						// Tags are often in a separate comment block above the doc comment.
+						doc := commentText(ts.Doc)
+						if len(genDecl.Specs) == 1 {
+							doc = commentsBetween(file, previousEnd, genDecl.Pos())
+						}
+						pkg.types[ts.Name.Name] = &typeSpec{spec: ts, file: file, doc: doc}
+					}
+				}
+				previousEnd = decl.End()
+			}
+		}
+	}
+	g.packages[importPath] = pkg
+	return pkg, nil
+}
+
+func commentText(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	var lines []string
+	for _, c := range group.List {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(c.Text, "//")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentsBetween returns the text of the comments of the file between the two positions.
+func commentsBetween(file *ast.File, from, to token.Pos) string {
+	var texts []string
+	for _, group := range file.Comments {
+		if group.Pos() > from && group.End() < to {
+			texts = append(texts, commentText(group))
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+func hasCommentTag(doc string, tag string) bool {
+	for _, line := range strings.Split(doc, "\n") {
+		if line == "+"+tag || strings.HasPrefix(line, "+"+tag+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// isKind returns whether the type is a top-level object that embeds the TypeMeta.
+func isKind(ts *typeSpec) bool {
+	st, ok := ts.spec.Type.(*ast.StructType)
+	if !ok || !strings.Contains(ts.doc, "+k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object") {
+		return false
+	}
+	for _, field := range st.Fields.List {
+		if sel, ok := field.Type.(*ast.SelectorExpr); ok && len(field.Names) == 0 && sel.Sel.Name == "TypeMeta" {
+			return true
+		}
+	}
+	return false
+}
+
+// definitionName returns the name of the definition in the same format as the upstream spec, for example
+// io.k8s.api.core.v1.Pod.
+func definitionName(importPath, name string) string {
+	parts := strings.Split(importPath, "/")
+	domain := strings.Split(parts[0], ".")
+	for i, j := 0, len(domain)-1; i < j; i, j = i+1, j-1 {
+		domain[i], domain[j] = domain[j], domain[i]
+	}
+	return strings.Join(append(append(domain, parts[1:]...), name), ".")
+}
+
+// namedSchema returns the schema of the named type. Structs are added to the definitions and referenced.
+func (g *generator) namedSchema(pkg *sourcePackage, name string) (map[string]interface{}, error) {
+	if custom, ok := customTypes[pkg.path+"."+name]; ok {
+		return custom, nil
+	}
+	ts, ok := pkg.types[name]
+	if !ok {
+		return nil, fmt.Errorf("type %s.%s not found", pkg.path, name)
+	}
+	st, ok := ts.spec.Type.(*ast.StructType)
+	if !ok {
+		return g.exprSchema(pkg, ts.file, ts.spec.Type)
+	}
+	defName := definitionName(pkg.path, name)
+	ref := map[string]interface{}{"$ref": "#/definitions/" + defName}
+	if _, ok := g.definitions[defName]; ok {
+		return ref, nil
+	}
+	def := map[string]interface{}{"type": "object", "additionalProperties": false}
+	// Add the definition before the fields so that recursive types refer to it.
+	g.definitions[defName] = def
+	properties := make(map[string]interface{})
+	var required []string
+	if err := g.addFields(pkg, ts.file, st, properties, &required); err != nil {
+		return nil, fmt.Errorf("%s: %w", defName, err)
+	}
+	def["properties"] = properties
+	if len(required) > 0 {
+		def["required"] = required
+	}
+	return ref, nil
+}
+
+// addFields adds the properties and required fields of the struct, including those of inlined structs.
+func (g *generator) addFields(pkg *sourcePackage, file *ast.File, st *ast.StructType, properties map[string]interface{}, required *[]string) error {
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(raw)
+		}
+		jsonTag, _ := tag.Lookup("json")
+		jsonName, jsonOptions, _ := strings.Cut(jsonTag, ",")
+		if jsonName == "-" {
+			continue
+		}
+		if len(field.Names) == 0 && jsonName == "" {
+			embeddedPkg, embeddedFile, embedded, err := g.resolveStruct(pkg, file, field.Type)
+			if err != nil {
+				return err
+			}
+			if err := g.addFields(embeddedPkg, embeddedFile, embedded, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(field.Names) > 0 && !field.Names[0].IsExported() {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Names[0].Name
+		}
+		schema, err := g.exprSchema(pkg, file, field.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %w", jsonName, err)
+		}
+		properties[jsonName] = schema
+
+		doc := commentText(field.Doc)
+		optional := hasCommentTag(doc, "optional")
+		if !optional && !hasCommentTag(doc, "required") {
+			optional = strings.Contains(","+jsonOptions+",", ",omitempty,")
+		}
+		if !optional {
+			*required = append(*required, jsonName)
+		}
+	}
+	return nil
+}
+
+// resolveStruct returns the struct type of an embedded field.
+func (g *generator) resolveStruct(pkg *sourcePackage, file *ast.File, expr ast.Expr) (*sourcePackage, *ast.File, *ast.StructType, error) {
+	var name string
+	switch e := expr.(type) {
+	case *ast.Ident:
+		name = e.Name
+	case *ast.SelectorExpr:
+		var err error
+		if pkg, err = g.importedPackage(pkg, file, e); err != nil {
+			return nil, nil, nil, err
+		}
+		name = e.Sel.Name
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported embedded field type %T", expr)
+	}
+	ts, ok := pkg.types[name]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("type %s.%s not found", pkg.path, name)
+	}
+	st, ok := ts.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("embedded type %s.%s is not a struct", pkg.path, name)
+	}
+	return pkg, ts.file, st, nil
+}
+
+func (g *generator) importedPackage(pkg *sourcePackage, file *ast.File, e *ast.SelectorExpr) (*sourcePackage, error) {
+	alias, _ := e.X.(*ast.Ident)
+	if alias == nil {
+		return nil, fmt.Errorf("unsupported selector %T", e.X)
+	}
+	importPath, ok := pkg.imports[file][alias.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown import %s", alias.Name)
+	}
+	return g.loadPackage(importPath)
+}
+
+// exprSchema returns the schema of a type expression.
+func (g *generator) exprSchema(pkg *sourcePackage, file *ast.File, expr ast.Expr) (map[string]interface{}, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			return map[string]interface{}{"type": "string"}, nil
+		case "bool":
+			return map[string]interface{}{"type": "boolean"}, nil
+		case "int", "int64", "uint64":
+			return map[string]interface{}{"type": "integer", "format": "int64"}, nil
+		case "int8", "int16", "int32", "uint8", "uint16", "uint32", "byte":
+			return map[string]interface{}{"type": "integer", "format": "int32"}, nil
+		case "float32", "float64":
+			return map[string]interface{}{"type": "number", "format": "double"}, nil
+		}
+		return g.namedSchema(pkg, e.Name)
+	case *ast.SelectorExpr:
+		imported, err := g.importedPackage(pkg, file, e)
+		if err != nil {
+			return nil, err
+		}
+		return g.namedSchema(imported, e.Sel.Name)
+	case *ast.StarExpr:
+		return g.exprSchema(pkg, file, e.X)
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return map[string]interface{}{"type": "string", "format": "byte"}, nil
+		}
+		items, err := g.exprSchema(pkg, file, e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case *ast.MapType:
+		values, err := g.exprSchema(pkg, file, e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case *ast.InterfaceType:
+		return map[string]interface{}{}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", expr)
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import "k8s.io/apimachinery/pkg/runtime/schema"
+
+// apiLifecycle is the range of Kubernetes minor versions that serve an api version of a kind. A removed value of 0
+// means the api version is still served.
+type apiLifecycle struct {
+	introduced int
+	removed    int
+}
+
+// apiLifecycles lists when api versions were introduced or removed for the kinds that are commonly generated. A kind of
+// * applies to every kind in the group version. Kinds that are not listed are assumed to be served by all versions.
+// See https://kubernetes.io/docs/reference/using-api/deprecation-guide/.
+var apiLifecycles = map[schema.GroupVersionKind]apiLifecycle{
+	{Group: "extensions", Version: "v1beta1", Kind: "*"}:                      {removed: 22},
+	{Group: "extensions", Version: "v1beta1", Kind: "Deployment"}:             {removed: 16},
+	{Group: "extensions", Version: "v1beta1", Kind: "DaemonSet"}:              {removed: 16},
+	{Group: "extensions", Version: "v1beta1", Kind: "ReplicaSet"}:             {removed: 16},
+	{Group: "extensions", Version: "v1beta1", Kind: "NetworkPolicy"}:          {removed: 16},
+	{Group: "apps", Version: "v1beta1", Kind: "*"}:                            {removed: 16},
+	{Group: "apps", Version: "v1beta2", Kind: "*"}:                            {removed: 16},
+	{Group: "apps", Version: "v1", Kind: "*"}:                                 {introduced: 9},
+	{Group: "batch", Version: "v1", Kind: "CronJob"}:                          {introduced: 21},
+	{Group: "batch", Version: "v1beta1", Kind: "CronJob"}:                     {removed: 25},
+	{Group: "autoscaling", Version: "v2", Kind: "*"}:                          {introduced: 23},
+	{Group: "autoscaling", Version: "v2beta1", Kind: "*"}:                     {removed: 25},
+	{Group: "autoscaling", Version: "v2beta2", Kind: "*"}:                     {removed: 26},
+	{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}:             {introduced: 21},
+	{Group: "policy", Version: "v1beta1", Kind: "*"}:                          {removed: 25},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}:              {introduced: 19},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "IngressClass"}:         {introduced: 19},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}:         {removed: 22},
+	{Group: "networking.k8s.io", Version: "v1beta1", Kind: "IngressClass"}:    {removed: 22},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "*"}:            {introduced: 8},
+	{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "*"}:       {removed: 22},
+	{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "*"}:               {removed: 22},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIStorageCapacity"}: {removed: 27},
+	{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Kind: "*"}:    {removed: 32},
+	{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "*"}:            {removed: 22},
+	{Group: "admissionregistration.k8s.io", Version: "v1beta1", Kind: "*"}:    {removed: 22},
+	{Group: "certificates.k8s.io", Version: "v1beta1", Kind: "*"}:             {removed: 22},
+	{Group: "coordination.k8s.io", Version: "v1beta1", Kind: "*"}:             {removed: 22},
+	{Group: "discovery.k8s.io", Version: "v1beta1", Kind: "*"}:                {removed: 25},
+	{Group: "node.k8s.io", Version: "v1beta1", Kind: "*"}:                     {removed: 25},
+	{Group: "events.k8s.io", Version: "v1beta1", Kind: "*"}:                   {removed: 25},
+	{Group: "apiregistration.k8s.io", Version: "v1beta1", Kind: "*"}:          {removed: 22},
+	{Group: "authentication.k8s.io", Version: "v1beta1", Kind: "*"}:           {removed: 22},
+	{Group: "authorization.k8s.io", Version: "v1beta1", Kind: "*"}:            {removed: 22},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIDriver"}:          {removed: 22},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSINode"}:            {removed: 22},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "StorageClass"}:       {removed: 22},
+	{Group: "storage.k8s.io", Version: "v1beta1", Kind: "VolumeAttachment"}:   {removed: 22},
+}
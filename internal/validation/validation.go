@@ -0,0 +1,363 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validation validates generated manifests offline. Built-in Kubernetes kinds are validated against the
+// OpenAPI schemas bundled for the target Kubernetes version and the set of API versions it serves, while custom
+// resources are validated against the openAPIV3Schema of CustomResourceDefinitions loaded from a directory.
+package validation
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sValidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+//go:generate go run ./schemagen -out schemas 1.34=v0.34.1 1.36=v0.36.2
+
+// bundledSchemas contains the OpenAPI definitions of the built-in kinds for each bundled Kubernetes release, named
+// like v1.36.json.
+//
+//go:embed schemas/*.json
+var bundledSchemas embed.FS
+
+// DefaultKubeVersion is the Kubernetes version validated against when none is specified. This matches the newest
+// bundled schemas.
+const DefaultKubeVersion = "1.36"
+
+// Validator validates manifests for a particular Kubernetes minor version.
+type Validator struct {
+	kubeMinor   int
+	compiler    *jsonschema.Compiler
+	schemaUrl   string
+	definitions map[schema.GroupVersionKind]string
+	schemas     map[schema.GroupVersionKind]*jsonschema.Schema
+	crdSchemas  map[schema.GroupVersionKind]*jsonschema.Schema
+}
+
+// NewValidator returns a validator for the given Kubernetes version. Built-in kinds are validated against the schemas
+// of the newest bundled release that is not newer than the version, or the oldest bundled release when the version is
+// older than all of them. Any CustomResourceDefinition manifests found in the yaml or json files of the optional
+// schemas directory are used to validate custom resources.
+func NewValidator(kubeVersion string, schemasDir string) (*Validator, error) {
+	minor, err := parseKubeMinorVersion(kubeVersion)
+	if err != nil {
+		return nil, err
+	}
+	v := &Validator{
+		kubeMinor:   minor,
+		definitions: make(map[schema.GroupVersionKind]string),
+		schemas:     make(map[schema.GroupVersionKind]*jsonschema.Schema),
+		crdSchemas:  make(map[schema.GroupVersionKind]*jsonschema.Schema),
+	}
+	if err := v.loadBundledSchemas(); err != nil {
+		return nil, err
+	}
+	if schemasDir != "" {
+		if err := v.loadCrdSchemas(schemasDir); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// parseKubeMinorVersion parses a version like 1.30, v1.30, or 1.30.2 and returns the minor version.
+func parseKubeMinorVersion(kubeVersion string) (int, error) {
+	parts := strings.Split(strings.TrimPrefix(kubeVersion, "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "1" {
+		return 0, fmt.Errorf("invalid kubernetes version '%s', expected a version like 1.30", kubeVersion)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor < 0 {
+		return 0, fmt.Errorf("invalid kubernetes version '%s', expected a version like 1.30", kubeVersion)
+	}
+	return minor, nil
+}
+
+// bundledReleases returns the minor versions of the bundled schemas in ascending order.
+func bundledReleases() ([]int, error) {
+	entries, err := bundledSchemas.ReadDir("schemas")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		minor, err := parseKubeMinorVersion(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("bundled schema '%s': %w", entry.Name(), err)
+		}
+		out = append(out, minor)
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func (v *Validator) loadBundledSchemas() error {
+	releases, err := bundledReleases()
+	if err != nil {
+		return err
+	} else if len(releases) == 0 {
+		return fmt.Errorf("no bundled schemas found")
+	}
+	release := releases[0]
+	for _, r := range releases {
+		if r <= v.kubeMinor {
+			release = r
+		}
+	}
+	if release != v.kubeMinor {
+		slog.Warn(fmt.Sprintf("No bundled schemas for Kubernetes 1.%d, validating against the schemas of 1.%d", v.kubeMinor, release))
+	}
+
+	name := fmt.Sprintf("v1.%d.json", release)
+	raw, err := bundledSchemas.ReadFile("schemas/" + name)
+	if err != nil {
+		return err
+	}
+	var decoded struct {
+		Definitions map[string]struct {
+			GroupVersionKinds []schema.GroupVersionKind `json:"x-kubernetes-group-version-kind"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("failed to decode bundled schema '%s': %w", name, err)
+	}
+	for definition, d := range decoded.Definitions {
+		for _, gvk := range d.GroupVersionKinds {
+			v.definitions[gvk] = definition
+		}
+	}
+	v.schemaUrl = "bundled://schemas/" + name
+	v.compiler = jsonschema.NewCompiler()
+	v.compiler.Draft = jsonschema.Draft4
+	if err := v.compiler.AddResource(v.schemaUrl, bytes.NewReader(raw)); err != nil {
+		return fmt.Errorf("failed to load bundled schema '%s': %w", name, err)
+	}
+	return nil
+}
+
+// bundledSchema returns the compiled schema of a built-in kind, or false when the kind is not bundled.
+func (v *Validator) bundledSchema(gvk schema.GroupVersionKind) (*jsonschema.Schema, bool, error) {
+	if compiled, ok := v.schemas[gvk]; ok {
+		return compiled, true, nil
+	}
+	definition, ok := v.definitions[gvk]
+	if !ok {
+		return nil, false, nil
+	}
+	compiled, err := v.compiler.Compile(v.schemaUrl + "#/definitions/" + definition)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to compile bundled schema for %s: %w", gvk, err)
+	}
+	v.schemas[gvk] = compiled
+	return compiled, true, nil
+}
+
+func (v *Validator) loadCrdSchemas(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read schemas directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(entry.Name())) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read schema file '%s': %w", path, err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(raw))
+		for {
+			var doc map[string]interface{}
+			if err := dec.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("failed to decode schema file '%s': %w", path, err)
+			}
+			if doc["kind"] != "CustomResourceDefinition" {
+				continue
+			}
+			if err := v.addCrdSchemas(doc); err != nil {
+				return fmt.Errorf("schema file '%s': %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Validator) addCrdSchemas(crd map[string]interface{}) error {
+	var decoded struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			Group string `json:"group"`
+			Names struct {
+				Kind string `json:"kind"`
+			} `json:"names"`
+			Versions []struct {
+				Name   string `json:"name"`
+				Schema struct {
+					OpenAPIV3Schema map[string]interface{} `json:"openAPIV3Schema"`
+				} `json:"schema"`
+			} `json:"versions"`
+		} `json:"spec"`
+	}
+	raw, _ := json.Marshal(crd)
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("failed to decode custom resource definition: %w", err)
+	}
+	for _, version := range decoded.Spec.Versions {
+		if version.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		gvk := schema.GroupVersionKind{Group: decoded.Spec.Group, Version: version.Name, Kind: decoded.Spec.Names.Kind}
+		url := fmt.Sprintf("crd://%s/%s", decoded.Metadata.Name, version.Name)
+		rawSchema, _ := json.Marshal(version.Schema.OpenAPIV3Schema)
+		compiled, err := jsonschema.CompileString(url, string(rawSchema))
+		if err != nil {
+			return fmt.Errorf("failed to compile schema for %s: %w", gvk, err)
+		}
+		v.crdSchemas[gvk] = compiled
+	}
+	return nil
+}
+
+// Validate validates a single manifest and returns the list of problems found.
+func (v *Validator) Validate(manifest map[string]interface{}) []string {
+	apiVersion, _ := manifest["apiVersion"].(string)
+	kind, _ := manifest["kind"].(string)
+	if apiVersion == "" || kind == "" {
+		return []string{"apiVersion and kind are required"}
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return []string{fmt.Sprintf("apiVersion: %v", err)}
+	}
+	gvk := gv.WithKind(kind)
+
+	var problems []string
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	if name, _ := metadata["name"].(string); name == "" {
+		problems = append(problems, "metadata.name is required")
+	} else if errs := k8sValidation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		problems = append(problems, fmt.Sprintf("metadata.name: %s", strings.Join(errs, ", ")))
+	}
+
+	if p := v.checkServed(gvk); p != "" {
+		return append(problems, p)
+	}
+
+	raw, err := json.Marshal(manifest)
+	if err != nil {
+		return append(problems, fmt.Sprintf("failed to encode manifest as json: %v", err))
+	}
+
+	compiled, ok := v.crdSchemas[gvk]
+	if !ok {
+		if compiled, ok, err = v.bundledSchema(gvk); err != nil {
+			return append(problems, err.Error())
+		} else if !ok {
+			slog.Warn(fmt.Sprintf("No schema found for %s, skipping schema validation", gvk))
+			return problems
+		}
+	}
+	var instance interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	_ = dec.Decode(&instance)
+	if err := compiled.Validate(withoutNulls(instance)); err != nil {
+		return append(problems, FlattenSchemaError(err)...)
+	}
+	return problems
+}
+
+// withoutNulls removes the null fields of the objects in the value, since Kubernetes treats them as unset.
+func withoutNulls(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			if v != nil {
+				out[k] = withoutNulls(v)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, v := range typed {
+			out[i] = withoutNulls(v)
+		}
+		return out
+	}
+	return value
+}
+
+// checkServed returns a problem if the api version of the kind is not served by the target Kubernetes version.
+func (v *Validator) checkServed(gvk schema.GroupVersionKind) string {
+	lc, ok := apiLifecycles[gvk]
+	if !ok {
+		lc, ok = apiLifecycles[gvk.GroupVersion().WithKind("*")]
+	}
+	if !ok {
+		return ""
+	}
+	apiVersion, _ := gvk.ToAPIVersionAndKind()
+	if v.kubeMinor < lc.introduced {
+		return fmt.Sprintf("%s %s is not available in Kubernetes 1.%d (introduced in 1.%d)", apiVersion, gvk.Kind, v.kubeMinor, lc.introduced)
+	} else if lc.removed > 0 && v.kubeMinor >= lc.removed {
+		return fmt.Sprintf("%s %s is not available in Kubernetes 1.%d (removed in 1.%d)", apiVersion, gvk.Kind, v.kubeMinor, lc.removed)
+	}
+	return ""
+}
+
+// FlattenSchemaError converts a json schema validation error into a list of leaf problems with their locations.
+func FlattenSchemaError(err error) []string {
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []string{err.Error()}
+	}
+	var out []string
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			location := strings.TrimPrefix(strings.ReplaceAll(e.InstanceLocation, "/", "."), ".")
+			if location == "" {
+				out = append(out, e.Message)
+			} else {
+				out = append(out, fmt.Sprintf("%s: %s", location, e.Message))
+			}
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+	return out
+}
@@ -0,0 +1,276 @@
+// Copyright 2024 The Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func decodeManifest(t *testing.T, raw string) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(raw), &out))
+	return out
+}
+
+func TestNewValidator_kube_version(t *testing.T) {
+	for _, v := range []string{"1.30", "v1.30", "1.30.2"} {
+		validator, err := NewValidator(v, "")
+		require.NoError(t, err)
+		assert.Equal(t, 30, validator.kubeMinor)
+	}
+	for _, v := range []string{"", "1", "2.0", "1.x", "1.30.1.1"} {
+		_, err := NewValidator(v, "")
+		assert.EqualError(t, err, "invalid kubernetes version '"+v+"', expected a version like 1.30")
+	}
+}
+
+func TestValidate_builtin(t *testing.T) {
+	validator, err := NewValidator(DefaultKubeVersion, "")
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		assert.Empty(t, validator.Validate(decodeManifest(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: example
+  template:
+    spec:
+      containers:
+        - name: main
+          image: nginx
+          resources:
+            limits:
+              memory: 128Mi
+              cpu: 1
+          ports:
+            - containerPort: 8080
+          livenessProbe:
+            httpGet:
+              port: http
+`)))
+	})
+
+	t.Run("null fields are unset", func(t *testing.T) {
+		assert.Empty(t, validator.Validate(decodeManifest(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+  creationTimestamp: null
+data: null
+`)))
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`spec.template.spec.containers.0: additionalProperties 'imagee' not allowed`,
+		}, validator.Validate(decodeManifest(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+spec:
+  selector: {}
+  template:
+    spec:
+      containers:
+        - name: main
+          imagee: nginx
+`)))
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`spec.replicas: expected integer, but got string`,
+		}, validator.Validate(decodeManifest(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+spec:
+  replicas: two
+  selector: {}
+  template: {}
+`)))
+	})
+
+	t.Run("missing required fields", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`spec: missing properties: 'template'`,
+			`spec.selector.matchExpressions.0: missing properties: 'operator'`,
+		}, validator.Validate(decodeManifest(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+spec:
+  selector:
+    matchExpressions:
+      - key: app
+`)))
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		problems := validator.Validate(decodeManifest(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: Not_Valid
+`))
+		require.Len(t, problems, 1)
+		assert.Contains(t, problems[0], "metadata.name: a lowercase RFC 1123 subdomain must consist of")
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		assert.Equal(t, []string{"metadata.name is required"}, validator.Validate(decodeManifest(t, `
+apiVersion: v1
+kind: ConfigMap
+`)))
+	})
+
+	t.Run("unknown kind is skipped", func(t *testing.T) {
+		assert.Empty(t, validator.Validate(decodeManifest(t, `
+apiVersion: example.com/v1
+kind: Thing
+metadata:
+  name: example
+spec:
+  anything: goes
+`)))
+	})
+}
+
+func TestValidate_kube_version(t *testing.T) {
+	cronJob := `
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: example
+`
+	old, err := NewValidator("1.20", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"batch/v1 CronJob is not available in Kubernetes 1.20 (introduced in 1.21)"}, old.Validate(decodeManifest(t, cronJob)))
+
+	current, err := NewValidator("1.30", "")
+	require.NoError(t, err)
+	assert.Empty(t, current.Validate(decodeManifest(t, cronJob)))
+	assert.Equal(t, []string{"policy/v1beta1 PodDisruptionBudget is not available in Kubernetes 1.30 (removed in 1.25)"}, current.Validate(decodeManifest(t, `
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: example
+`)))
+}
+
+func TestValidate_bundled_release(t *testing.T) {
+	releases, err := bundledReleases()
+	require.NoError(t, err)
+	assert.Equal(t, []int{34, 36}, releases)
+
+	// The schedulingGroup field of the PodSpec was added in 1.36.
+	pod := decodeManifest(t, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: example
+spec:
+  schedulingGroup:
+    podGroupName: example
+  containers:
+    - name: main
+      image: nginx
+`)
+	for version, expected := range map[string][]string{
+		"1.30": {"spec: additionalProperties 'schedulingGroup' not allowed"},
+		"1.35": {"spec: additionalProperties 'schedulingGroup' not allowed"},
+		"1.36": nil,
+		"1.40": nil,
+	} {
+		validator, err := NewValidator(version, "")
+		require.NoError(t, err)
+		assert.Equal(t, expected, validator.Validate(pod), version)
+	}
+}
+
+func TestValidate_crd_schemas(t *testing.T) {
+	td := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(td, "crds.yaml"), []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: things.example.com
+spec:
+  group: example.com
+  names:
+    kind: Thing
+  versions:
+    - name: v1
+      schema:
+        openAPIV3Schema:
+          type: object
+          properties:
+            spec:
+              type: object
+              required: ["size"]
+              properties:
+                size:
+                  type: integer
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "README.md"), []byte(`not a schema`), 0644))
+	validator, err := NewValidator(DefaultKubeVersion, td)
+	require.NoError(t, err)
+
+	assert.Empty(t, validator.Validate(decodeManifest(t, `
+apiVersion: example.com/v1
+kind: Thing
+metadata:
+  name: example
+spec:
+  size: 3
+`)))
+	assert.Equal(t, []string{"spec: missing properties: 'size'"}, validator.Validate(decodeManifest(t, `
+apiVersion: example.com/v1
+kind: Thing
+metadata:
+  name: example
+spec: {}
+`)))
+	assert.Equal(t, []string{"spec.size: expected integer, but got string"}, validator.Validate(decodeManifest(t, `
+apiVersion: example.com/v1
+kind: Thing
+metadata:
+  name: example
+spec:
+  size: big
+`)))
+}